@@ -0,0 +1,251 @@
+package console
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DisplayWidth returns the number of terminal columns s occupies, ignoring ANSI
+// escape sequences (such as the style codes in a Theme, or terminal hyperlinks).  It's the
+// measurement the handler pads and truncates header fields with, see RuneWidth.
+func DisplayWidth(s string) int {
+	return displayWidth([]byte(s))
+}
+
+// RuneWidth returns the number of terminal columns r occupies: 0 for characters which
+// don't advance the cursor, like combining accents, zero-width joiners, variation
+// selectors and bidirectional text controls, which join with or affect the character
+// before or after them, 2 for East Asian wide and fullwidth characters and emoji, and
+// 1 for all others.
+func RuneWidth(r rune) int {
+	switch {
+	case r < utf8.RuneSelf:
+		return 1
+	case r == '\u00ad':
+		// the soft hyphen is a format character, but terminals show it
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= wideRunes[0].lo && isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRunes are the ranges of characters with the East Asian Width property
+// W (wide) or F (fullwidth), which includes most emoji, sorted.
+var wideRunes = []struct{ lo, hi rune }{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F265}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func isWide(r rune) bool {
+	i := sort.Search(len(wideRunes), func(i int) bool { return wideRunes[i].hi >= r })
+	return i < len(wideRunes) && wideRunes[i].lo <= r
+}
+
+// Truncate shortens s to at most width columns.  ANSI escape sequences are never split, and
+// are retained even if the text around them is dropped, so styles opened in s are still
+// closed.
+func Truncate(s string, width int) string {
+	return string(appendTruncated(nil, []byte(s), width))
+}
+
+// Pad pads s with spaces to width columns, ignoring ANSI escape sequences when measuring.
+// If rightAlign is true, the padding is added to the left.  s is returned unchanged if it
+// is already at least width columns wide.
+func Pad(s string, width int, rightAlign bool) string {
+	n := width - DisplayWidth(s)
+	if n <= 0 {
+		return s
+	}
+	if rightAlign {
+		return strings.Repeat(" ", n) + s
+	}
+	return s + strings.Repeat(" ", n)
+}
+
+// escapeLen returns the length of the ANSI escape sequence at the start of b,
+// or 0 if b doesn't start with one.  Handles CSI sequences (like SGR styles),
+// OSC sequences (like hyperlinks), and simple two byte escapes.  An unterminated
+// sequence extends to the end of b.
+func escapeLen(b []byte) int {
+	if len(b) < 2 || b[0] != '\x1b' {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+		// CSI: parameters and intermediates, terminated by a byte in 0x40-0x7E
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(b)
+	case ']':
+		// OSC: terminated by BEL or ST (ESC \)
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(b)
+	default:
+		return 2
+	}
+}
+
+func displayWidth(b []byte) int {
+	var w int
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			i += n
+			continue
+		}
+		if b[i] < utf8.RuneSelf {
+			i++
+			w++
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		i += size
+		if r != zwj || i == len(b) || b[i] == '\x1b' {
+			w += RuneWidth(r)
+			continue
+		}
+		// the character after a zero-width joiner is drawn joined with
+		// the one before, as in emoji sequences
+		_, size = utf8.DecodeRune(b[i:])
+		i += size
+	}
+	return w
+}
+
+// zwj is the zero-width joiner.
+const zwj = '\u200d'
+
+// appendTruncated appends src to dst, dropping any visible characters past
+// width columns, but keeping all escape sequences.  Zero width characters are
+// kept or dropped with the character before them, so combining accents aren't
+// separated from their letters.  dst may share src's backing array, as long as
+// dst ends at or before the start of src.
+func appendTruncated(dst, src []byte, width int) []byte {
+	var w int
+	kept := true
+	for i := 0; i < len(src); {
+		if n := escapeLen(src[i:]); n > 0 {
+			dst = append(dst, src[i:i+n]...)
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRune(src[i:])
+		if r == zwj && i+size < len(src) && src[i+size] != '\x1b' {
+			// keep or drop the joined character along with the joiner
+			_, n := utf8.DecodeRune(src[i+size:])
+			size += n
+		}
+		if rw := RuneWidth(r); rw == 0 {
+			if kept {
+				dst = append(dst, src[i:i+size]...)
+			}
+		} else if kept = kept && w+rw <= width; kept {
+			dst = append(dst, src[i:i+size]...)
+			w += rw
+		}
+		i += size
+	}
+	return dst
+}
+
+// appendStripped appends b to dst with its ANSI escape sequences removed.
+func appendStripped(dst, b []byte) []byte {
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			i += n
+			continue
+		}
+		j := i + 1
+		for j < len(b) && b[j] != '\x1b' {
+			j++
+		}
+		dst = append(dst, b[i:j]...)
+		i = j
+	}
+	return dst
+}
+
+// Segment is a run of text rendered in one style, see ParseStyled and
+// HandlerOptions.OnRender.
+type Segment struct {
+	// Kind is the part of the record the text renders.  It's only set by OnRender;
+	// ParseStyled can't tell, so it leaves it SegmentText.
+	Kind SegmentKind
+	// Style is the ANSI escape sequences the text is styled with, concatenated, like
+	// "\x1b[1m\x1b[31m", or "" for unstyled text.
+	Style ANSIMod
+	Text  string
+}
+
+// ParseStyled splits s, like a line rendered by a Handler, into segments of text and the
+// styles they're rendered with, so tests can check a theme's output structurally:
+//
+//	segs := console.ParseStyled(buf.String())
+//	// []Segment{{Style: theme.Timestamp, Text: "3:04PM"}, {Text: " "}, ...}
+//
+// Styles are set by SGR escape sequences, and cleared by resets.  Styles opened inside
+// other styles are appended to them.  Adjacent segments with the same style are merged,
+// and other escape sequences, like hyperlinks, are dropped.
+func ParseStyled(s string) []Segment {
+	var segs []Segment
+	var style ANSIMod
+	b := []byte(s)
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			seq := s[i : i+n]
+			switch {
+			case seq == string(ResetMod) || seq == "\x1b[m":
+				style = ""
+			case b[i+1] == '[' && seq[n-1] == 'm':
+				style += ANSIMod(seq)
+			}
+			i += n
+			continue
+		}
+		j := i + 1
+		for j < len(b) && b[j] != '\x1b' {
+			j++
+		}
+		if last := len(segs) - 1; last >= 0 && segs[last].Style == style {
+			segs[last].Text += s[i:j]
+		} else {
+			segs = append(segs, Segment{Style: style, Text: s[i:j]})
+		}
+		i = j
+	}
+	return segs
+}
@@ -0,0 +1,203 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	red := string(ToANSICode(Red))
+	reset := string(ResetMod)
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"héllo", 5},
+		{red + "hello" + reset, 5},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", 4},
+		{"\x1b]8;;https://example.com\alink\x1b]8;;\a", 4},
+		{"unterminated\x1b[1", 12},
+		{"he\u0301llo", 5},                    // combining acute accent
+		{"👩\u200d💻", 2},                       // zero-width joiner
+		{"\u2067abc\u2069", 3},                // bidi isolate
+		{"\u05e9\u05c1\u05dc\u05d5\u05dd", 4}, // hebrew with a point
+		{"soft\u00adhyphen", 11},
+		{"日本語", 6},
+		{"ｈｉ", 4}, // fullwidth latin
+		{"🚀 go", 5},
+	}
+	for _, tt := range tests {
+		AssertEqual(t, tt.want, DisplayWidth(tt.in))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	red := string(ToANSICode(Red))
+	reset := string(ResetMod)
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"hello", 3, "hel"},
+		{"hello", 10, "hello"},
+		{"héllo", 2, "hé"},
+		{red + "hello" + reset, 2, red + "he" + reset},
+		{red + "he" + reset + " " + red + "llo" + reset, 2, red + "he" + reset + red + reset},
+		{"hello", 0, ""},
+		{"he\u0301llo", 2, "he\u0301"},
+		{"he\u0301llo", 1, "h"},
+		{"\u2067abc\u2069", 2, "\u2067ab"},
+		{"日本語", 4, "日本"},
+		{"日本語", 5, "日本"},
+		{"👩\u200d💻!", 2, "👩\u200d💻"},
+		{"a👩\u200d💻", 2, "a"},
+	}
+	for _, tt := range tests {
+		AssertEqual(t, tt.want, Truncate(tt.in, tt.width))
+	}
+}
+
+func TestPad(t *testing.T) {
+	red := string(ToANSICode(Red))
+	reset := string(ResetMod)
+	AssertEqual(t, "ab   ", Pad("ab", 5, false))
+	AssertEqual(t, "   ab", Pad("ab", 5, true))
+	AssertEqual(t, red+"ab"+reset+"   ", Pad(red+"ab"+reset, 5, false))
+	AssertEqual(t, "abcdef", Pad("abcdef", 5, false))
+	AssertEqual(t, "e\u0301  ", Pad("e\u0301", 3, false))
+}
+
+func TestHandler_HeaderWidthMultibyte(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "combining",
+			attrs: []slog.Attr{slog.String("foo", "cafe\u0301")},
+			want:  "cafe\u0301     > msg\n",
+		},
+		{
+			name:  "pad",
+			attrs: []slog.Attr{slog.String("foo", "héllo")},
+			want:  "héllo    > msg\n",
+		},
+		{
+			name:  "truncate wide",
+			attrs: []slog.Attr{slog.String("foo", "日本語のテキスト")},
+			want:  "日本語の > msg\n",
+		},
+		{
+			name:  "wide rune past the last column",
+			attrs: []slog.Attr{slog.String("foo", "a日本語の")},
+			want:  "a日本語  > msg\n",
+		},
+		{
+			name:  "pad wide",
+			attrs: []slog.Attr{slog.String("foo", "日本")},
+			want:  "日本     > msg\n",
+		},
+		{
+			name:  "truncate without splitting runes",
+			attrs: []slog.Attr{slog.String("foo", "ééééééééé")},
+			want:  "éééééééé > msg\n",
+		},
+	}
+	for _, tt := range tests {
+		tt.msg = "msg"
+		tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%[foo]8h > %m"}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestAppendStripped(t *testing.T) {
+	red := string(ToANSICode(Red))
+	reset := string(ResetMod)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"hello", "hello"},
+		{red + "hello" + reset + " world", "hello world"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"\r\x1b[Kline", "\rline"},
+	}
+	for _, tt := range tests {
+		AssertEqual(t, tt.want, string(appendStripped(nil, []byte(tt.in))))
+	}
+}
+
+func TestParseStyled(t *testing.T) {
+	red, bold := ToANSICode(Red), ToANSICode(Bold)
+	tests := []struct {
+		in   string
+		want []Segment
+	}{
+		{in: "", want: nil},
+		{in: "plain", want: []Segment{{Text: "plain"}}},
+		{
+			in:   styled("a", red) + " b " + styled("c", bold),
+			want: []Segment{{Style: red, Text: "a"}, {Text: " b "}, {Style: bold, Text: "c"}},
+		},
+		{
+			// nested styles are appended, and adjacent segments with the same style merged
+			in:   string(red) + "a" + string(bold) + "b" + string(ResetMod) + "c" + string(ResetMod) + "d",
+			want: []Segment{{Style: red, Text: "a"}, {Style: red + bold, Text: "b"}, {Text: "cd"}},
+		},
+		{
+			// hyperlinks are dropped
+			in:   "\x1b]8;;http://x\x1b\\" + styled("link", red) + "\x1b]8;;\x1b\\",
+			want: []Segment{{Style: red, Text: "link"}},
+		},
+	}
+	for _, tt := range tests {
+		got := ParseStyled(tt.in)
+		AssertEqual(t, fmt.Sprintf("%q", tt.want), fmt.Sprintf("%q", got))
+	}
+}
+
+func TestParseStyled_Handler(t *testing.T) {
+	// ForceColor keeps the theme when captured
+	var buf bytes.Buffer
+	theme := NewDefaultTheme()
+	h := NewHandler(&buf, &HandlerOptions{StableWhenCaptured: true, ForceColor: true, Theme: theme})
+	slog.New(h).Warn("slow", "ms", 12)
+	AssertEqual(t, fmt.Sprintf("%q", []Segment{
+		{Style: theme.LevelWarn, Text: "WRN"},
+		{Text: " "},
+		{Style: theme.Message, Text: "slow"},
+		{Text: " "},
+		{Style: theme.AttrKey, Text: "ms="},
+		{Text: "12\n"},
+	}), fmt.Sprintf("%q", ParseStyled(buf.String())))
+}
+
+func FuzzTruncate(f *testing.F) {
+	f.Add("hello", 3)
+	f.Add("héllo", 2)
+	f.Add(string(ToANSICode(Red))+"日本語"+string(ResetMod), 2)
+	f.Add("\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", 1)
+	f.Add("unterminated\x1b[1", 5)
+	f.Fuzz(func(t *testing.T, s string, width int) {
+		width = max(width%100, 0)
+		got := Truncate(s, width)
+		if w := DisplayWidth(got); w > width {
+			t.Fatalf("Truncate(%q, %d) = %q, %d columns wide", s, width, got, w)
+		}
+		if DisplayWidth(s) <= width && got != s {
+			t.Fatalf("Truncate(%q, %d) = %q, want it unchanged", s, width, got)
+		}
+		// only the end of the text is dropped
+		if text := string(appendStripped(nil, []byte(got))); !strings.HasPrefix(string(appendStripped(nil, []byte(s))), text) {
+			t.Fatalf("Truncate(%q, %d) = %q, text %q isn't a prefix", s, width, got, text)
+		}
+		// padding an unterminated escape sequence extends it
+		if p := Pad(got, width, false); incompleteEscape([]byte(got)) == len(got) && DisplayWidth(p) != width {
+			t.Fatalf("Pad(%q, %d) = %q, %d columns wide", got, width, p, DisplayWidth(p))
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package console
+
+// orderedAttr is the location of an encoded attr which matched one of the keys in
+// HandlerOptions.AttrOrder.
+type orderedAttr struct {
+	// index of the matched key in AttrOrder
+	idx int
+	// location of the encoded attr in the order buffer
+	start, end int
+}
+
+// attrOrderIndex returns the index of the attr's key in AttrOrder, or -1.
+func (e *encoder) attrOrderIndex(groupPrefix, key string) int {
+	for i, k := range e.h.opts.AttrOrder {
+		if matchKey(k, groupPrefix, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveToOrderBuf moves the attr encoded at attrBuf[offset:] to the order buffer.
+func (e *encoder) moveToOrderBuf(idx, offset int) {
+	start := len(e.orderBuf)
+	e.orderBuf.Append(e.attrBuf[offset:])
+	e.orderAttrs = append(e.orderAttrs, orderedAttr{idx: idx, start: start, end: len(e.orderBuf)})
+	e.attrBuf = e.attrBuf[:offset]
+}
+
+// appendContext appends the handler's encoded context attrs.
+func (e *encoder) appendContext() {
+	e.attrBuf.Append(e.h.context)
+	e.multilineAttrBuf.Append(e.h.multilineContext)
+	e.appendOrderBuf(e.h.orderedContext, e.h.orderedContextAttrs)
+}
+
+// appendOrderBuf appends another order buffer, like the handler's context, to the
+// encoder's order buffer.
+func (e *encoder) appendOrderBuf(buf buffer, attrs []orderedAttr) {
+	offset := len(e.orderBuf)
+	e.orderBuf.Append(buf)
+	for _, a := range attrs {
+		a.start += offset
+		a.end += offset
+		e.orderAttrs = append(e.orderAttrs, a)
+	}
+}
+
+// applyAttrOrder moves the ordered attrs to the front of attrBuf, sorted by
+// their position in AttrOrder.  Attrs with the same key keep their relative order.
+func (e *encoder) applyAttrOrder() {
+	if len(e.orderAttrs) == 0 {
+		return
+	}
+
+	// stable insertion sort: there are only ever a few of these
+	for i := 1; i < len(e.orderAttrs); i++ {
+		for j := i; j > 0 && e.orderAttrs[j].idx < e.orderAttrs[j-1].idx; j-- {
+			e.orderAttrs[j], e.orderAttrs[j-1] = e.orderAttrs[j-1], e.orderAttrs[j]
+		}
+	}
+
+	// the order buffer is no longer needed, reuse it to assemble the result
+	end := len(e.orderBuf)
+	for _, a := range e.orderAttrs {
+		e.orderBuf = append(e.orderBuf, e.orderBuf[a.start:a.end]...)
+	}
+	e.orderBuf = append(e.orderBuf, e.attrBuf...)
+	e.attrBuf = append(e.attrBuf[:0], e.orderBuf[end:]...)
+}
@@ -0,0 +1,152 @@
+package console
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHandler_AttrOrder(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name: "ordered first",
+			attrs: []slog.Attr{
+				slog.String("foo", "bar"),
+				slog.Duration("duration", time.Second),
+				slog.Int("status", 500),
+				slog.Any("err", errors.New("boom")),
+			},
+			want: "INF req err=boom status=500 duration=1s foo=bar\n",
+		},
+		{
+			name: "missing keys",
+			attrs: []slog.Attr{
+				slog.String("foo", "bar"),
+				slog.Duration("duration", time.Second),
+			},
+			want: "INF req duration=1s foo=bar\n",
+		},
+		{
+			name: "context attrs",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("a", "1"), slog.Int("status", 200)}).
+					WithAttrs([]slog.Attr{slog.String("err", "ctx")})
+			},
+			attrs: []slog.Attr{
+				slog.String("b", "2"),
+				slog.Any("err", errors.New("boom")),
+			},
+			want: "INF req err=ctx err=boom status=200 a=1 b=2\n",
+		},
+		{
+			name: "groups",
+			attrs: []slog.Attr{
+				slog.String("foo", "bar"),
+				slog.Group("req", slog.String("method", "GET"), slog.Int("status", 404)),
+			},
+			want: "INF req req.status=404 foo=bar req.method=GET\n",
+		},
+		{
+			name: "multiline attrs are not moved",
+			attrs: []slog.Attr{
+				slog.String("foo", "bar"),
+				slog.String("err", "line 1\nline 2"),
+			},
+			want: "INF req foo=bar\n=== err ===\nline 1\nline 2\n",
+		},
+		{
+			name: "only ordered attrs",
+			attrs: []slog.Attr{
+				slog.Int("status", 500),
+			},
+			want: "INF req status=500\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "req"
+		tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", AttrOrder: []string{"err", "status", "req.status", "duration"}}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_AttrOrderWithHeaders(t *testing.T) {
+	handlerTest{
+		msg:  "req",
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", AttrOrder: []string{"status"}},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("a", "1"), slog.Int("status", 200), slog.String("id", "x")}).(*Handler).WithHeaders("id")
+		},
+		attrs: []slog.Attr{slog.String("b", "2")},
+		want:  "INF x req status=200 a=1 b=2\n",
+	}.run(t)
+}
+
+func TestHandler_AttrOrderSemantics(t *testing.T) {
+	// the context's attrs, headers, ordered attrs, multiline values and groups, and the
+	// record's, with the same keys
+	context := func(h slog.Handler) slog.Handler {
+		return h.WithAttrs([]slog.Attr{slog.String("a", "ctx"), slog.String("id", "ctx"), slog.String("status", "ctx"), slog.String("m", "c\nc")}).
+			WithGroup("g").
+			WithAttrs([]slog.Attr{slog.String("b", "ctx")})
+	}
+	attrs := []slog.Attr{slog.String("b", "rec"), slog.String("status", "rec"), slog.String("m", "r\nr")}
+	tests := []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{
+			name: "context first",
+			want: "ctx msg status=ctx a=ctx g.b=ctx g.b=rec g.status=rec\n=== m ===\nc\nc\n=== g.m ===\nr\nr\n",
+		},
+		{
+			name: "context after",
+			opts: HandlerOptions{ContextAfterRecord: true},
+			want: "ctx msg status=ctx g.b=rec g.status=rec a=ctx g.b=ctx\n=== g.m ===\nr\nr\n=== m ===\nc\nc\n",
+		},
+		{
+			name: "per record",
+			opts: HandlerOptions{ReplaceAttrPerRecord: true, ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr { return a }},
+			want: "ctx msg status=ctx a=ctx g.b=ctx g.b=rec g.status=rec\n=== m ===\nc\nc\n=== g.m ===\nr\nr\n",
+		},
+		{
+			name: "per record, context after",
+			opts: HandlerOptions{ReplaceAttrPerRecord: true, ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr { return a }, ContextAfterRecord: true},
+			want: "ctx msg status=ctx g.b=rec g.status=rec a=ctx g.b=ctx\n=== g.m ===\nr\nr\n=== m ===\nc\nc\n",
+		},
+		{
+			name: "sorted",
+			opts: HandlerOptions{SortAttrs: true, ContextAfterRecord: true},
+			want: "ctx msg status=ctx a=ctx g.b=ctx g.b=rec g.status=rec\n=== g.m ===\nr\nr\n=== m ===\nc\nc\n",
+		},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.NoColor = true
+		opts.HeaderFormat = "%[id]h %m %a"
+		opts.AttrOrder = []string{"status"}
+		handlerTest{
+			name:        tt.name,
+			opts:        opts,
+			handlerFunc: context,
+			msg:         "msg",
+			attrs:       attrs,
+			want:        tt.want,
+		}.run(t)
+	}
+
+	// a record's header overrides the context's, either way
+	for _, after := range []bool{false, true} {
+		handlerTest{
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%[id]h %m %a", ContextAfterRecord: after},
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("id", "ctx")})
+			},
+			msg:   "msg",
+			attrs: []slog.Attr{slog.String("id", "rec")},
+			want:  "rec msg\n",
+		}.run(t)
+	}
+}
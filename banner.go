@@ -0,0 +1,57 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// Banner is the line printed once, when the handler is created, if HandlerOptions.Banner is
+// set, like:
+//
+//	myapp v1.2.3 pid=4242 level=INFO color=on
+type Banner struct {
+	// Name of the app, printed first
+	Name string
+	// Version of the app, printed after the name
+	Version string
+	// Attrs are printed after the built-in pid, level and color attrs
+	Attrs []slog.Attr
+	// HideDefaults omits the pid, level and color attrs
+	HideDefaults bool
+}
+
+// writeBanner writes the banner line.
+func (h *Handler) writeBanner() {
+	b := h.opts.Banner
+	enc := newEncoder(h)
+	if b.Name != "" {
+		enc.writeColoredString(&enc.buf, b.Name, h.opts.Theme.Message)
+	}
+	if b.Version != "" {
+		if len(enc.buf) > 0 {
+			enc.buf.AppendByte(' ')
+		}
+		enc.writeColoredString(&enc.buf, b.Version, h.opts.Theme.Header)
+	}
+	if !b.HideDefaults {
+		color := "on"
+		if h.opts.NoColor {
+			color = "off"
+		}
+		enc.writeAttr(&enc.attrBuf, slog.Int("pid", os.Getpid()), "")
+		enc.writeAttr(&enc.attrBuf, slog.String("level", h.opts.Level.Level().String()), "")
+		enc.writeAttr(&enc.attrBuf, slog.String("color", color), "")
+	}
+	for _, a := range b.Attrs {
+		enc.writeAttr(&enc.attrBuf, a, "")
+	}
+	attrs := enc.attrBuf
+	if len(enc.buf) == 0 && len(attrs) > 0 {
+		// no name or version, so drop the leading space
+		attrs = attrs[1:]
+	}
+	enc.buf.Append(attrs)
+	// errors are reported by OnWriteError and WriteFailure
+	_ = h.write(context.Background(), enc)
+}
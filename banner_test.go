@@ -0,0 +1,67 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestHandler_Banner(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name   string
+		opts   HandlerOptions
+		banner Banner
+		want   string
+	}{
+		{
+			name:   "default",
+			opts:   HandlerOptions{NoColor: true},
+			banner: Banner{Name: "myapp", Version: "v1.2.3"},
+			want:   "myapp v1.2.3 pid=" + pid + " level=INFO color=off\n",
+		},
+		{
+			name:   "attrs",
+			opts:   HandlerOptions{NoColor: true, Level: slog.LevelDebug},
+			banner: Banner{Name: "myapp", Attrs: []slog.Attr{slog.String("env", "prod")}},
+			want:   "myapp pid=" + pid + " level=DEBUG color=off env=prod\n",
+		},
+		{
+			name:   "hide defaults",
+			opts:   HandlerOptions{NoColor: true},
+			banner: Banner{Version: "v1", HideDefaults: true},
+			want:   "v1\n",
+		},
+		{
+			name:   "no name",
+			opts:   HandlerOptions{NoColor: true},
+			banner: Banner{HideDefaults: true, Attrs: []slog.Attr{slog.Int("a", 1)}},
+			want:   "a=1\n",
+		},
+		{
+			name:   "color",
+			banner: Banner{Name: "myapp", Version: "v1", HideDefaults: true},
+			want:   styled("myapp", NewDefaultTheme().Message) + " " + styled("v1", NewDefaultTheme().Header) + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			tt.opts.Banner = &tt.banner
+			h := NewHandler(&buf, &tt.opts)
+			AssertEqual(t, tt.want, buf.String())
+
+			// derived handlers don't print it again
+			buf.Reset()
+			h.WithAttrs([]slog.Attr{slog.Int("b", 2)}).WithGroup("g")
+			AssertEqual(t, "", buf.String())
+		})
+	}
+
+	// not printed by default
+	buf := bytes.Buffer{}
+	NewHandler(&buf, nil)
+	AssertEqual(t, "", buf.String())
+}
@@ -82,3 +82,37 @@ func BenchmarkLoggers(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkContextHeavy benchmarks the common shape of a service logger: many context
+// attributes added once, and short messages with few attributes.
+func BenchmarkContextHeavy(b *testing.B) {
+	ctx := context.Background()
+	var ctxAttrs []slog.Attr
+	for _, k := range []string{"service", "version", "env", "region", "host", "pod", "tenant", "user", "session", "request_id", "trace_id", "span_id"} {
+		ctxAttrs = append(ctxAttrs, slog.String(k, k+"-value"))
+	}
+	rec := slog.NewRecord(time.Now(), slog.LevelInfo, "handled", 0)
+	rec.AddAttrs(slog.Int("status", 200))
+
+	for _, tc := range handlers {
+		b.Run(tc.name, func(b *testing.B) {
+			l := tc.hdl.WithAttrs(ctxAttrs)
+			_ = l.Handle(ctx, rec)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = l.Handle(ctx, rec)
+			}
+		})
+	}
+
+	b.Run("console-nocolor-toggle", func(b *testing.B) {
+		h := NewHandler(io.Discard, &HandlerOptions{Level: slog.LevelDebug})
+		l := h.WithAttrs(ctxAttrs)
+		_ = l.Handle(ctx, rec)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h.SetNoColor(i%2 == 0)
+			_ = l.Handle(ctx, rec)
+		}
+	})
+}
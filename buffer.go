@@ -2,6 +2,7 @@ package console
 
 import (
 	"io"
+	"reflect"
 	"strconv"
 	"time"
 )
@@ -18,42 +19,123 @@ func (b *buffer) Pad(n int, c byte) {
 	}
 }
 
+// PadFrom pads the content of the buffer after start with spaces, up to width
+// columns.  If rightAlign is true, the content is shifted right, and the
+// spaces inserted before it.  ANSI escape sequences don't count towards the width.
+func (b *buffer) PadFrom(start, width int, rightAlign bool) {
+	remainingWidth := width - displayWidth((*b)[start:])
+	if remainingWidth <= 0 {
+		return
+	}
+	if !rightAlign {
+		b.Pad(remainingWidth, ' ')
+		return
+	}
+	// shift the text right in-place, then fill the left side with spaces
+	textLen := len(*b) - start
+	b.Pad(remainingWidth, ' ')
+	copy((*b)[start+remainingWidth:], (*b)[start:start+textLen])
+	for i := 0; i < remainingWidth; i++ {
+		(*b)[start+i] = ' '
+	}
+}
+
 func (b *buffer) WriteTo(dst io.Writer) (int64, error) {
+	return b.WriteChunksTo(dst, len(*b))
+}
+
+// WriteChunksTo writes the buffer to dst like WriteTo, but passes dst at most size bytes
+// at a time.  If it returns an error, the bytes which were written are removed from the
+// buffer, so it holds only the rest.
+func (b *buffer) WriteChunksTo(dst io.Writer, size int) (int64, error) {
 	l := len(*b)
 	if l == 0 {
 		return 0, nil
 	}
-	n, err := dst.Write(*b)
-	if err != nil {
-		return int64(n), err
-	}
-	if n < l {
-		return int64(n), io.ErrShortWrite
+	// writers may accept only part of the buffer without an error, so keep
+	// writing the rest until it's all written, the writer returns an error, or
+	// the writer stops making progress
+	var written, stalled int
+	for written < l {
+		n, err := dst.Write((*b)[written:min(written+size, l)])
+		written += n
+		if err != nil {
+			b.consume(written)
+			return int64(written), err
+		}
+		if n > 0 {
+			stalled = 0
+			continue
+		}
+		if stalled++; stalled >= maxStalledWrites {
+			b.consume(written)
+			return int64(written), &ShortWriteError{Written: written, Len: l}
+		}
 	}
 	b.Reset()
-	return int64(n), nil
+	return int64(written), nil
+}
+
+// consume removes the first n bytes from the buffer.
+func (b *buffer) consume(n int) {
+	*b = (*b)[:copy(*b, (*b)[n:])]
 }
 
+// maxStalledWrites is the number of writes in a row which may write nothing
+// before WriteTo gives up.
+const maxStalledWrites = 3
+
 func (b *buffer) Write(bt []byte) (int, error) {
 	*b = append(*b, bt...)
 	return len(bt), nil
 }
 
+// maxBufferSize is the largest buffer kept by Reset.  Larger buffers are dropped,
+// to reduce peak allocation: a huge record doesn't pin a huge buffer in the pool.
+const maxBufferSize = 16 << 10
+
 func (b *buffer) Reset() {
-	// To reduce peak allocation, return only smaller buffers to the pool.
-	const maxBufferSize = 16 << 10
 	if cap(*b) > maxBufferSize {
-		*b = (*b)[:0:maxBufferSize]
+		*b = nil
+		poolStats.discards.Add(1)
 		return
 	}
 	*b = (*b)[:0]
 }
 
+// maxGrowStep caps how much a buffer grows at once.  Buffers double in size until
+// then, and grow in steps of maxGrowStep after, so large multiline values are
+// copied fewer times than with append's growth, without overshooting by megabytes.
+const maxGrowStep = 1 << 20
+
+// grow grows the buffer's capacity to fit n more bytes.
+func (b *buffer) grow(n int) {
+	need := len(*b) + n
+	if need <= cap(*b) {
+		return
+	}
+	c := max(cap(*b)*2, 1024)
+	if c-cap(*b) > maxGrowStep {
+		c = cap(*b) + maxGrowStep
+	}
+	c = max(c, need)
+	nb := make(buffer, len(*b), c)
+	copy(nb, *b)
+	*b = nb
+	poolStats.grows.Add(1)
+}
+
 func (b *buffer) Append(data []byte) {
+	if len(*b)+len(data) > cap(*b) {
+		b.grow(len(data))
+	}
 	*b = append(*b, data...)
 }
 
 func (b *buffer) AppendString(s string) {
+	if len(*b)+len(s) > cap(*b) {
+		b.grow(len(s))
+	}
 	*b = append(*b, s...)
 }
 
@@ -77,10 +159,40 @@ func (b *buffer) AppendFloat(i float64) {
 	*b = strconv.AppendFloat(*b, i, 'g', -1, 64)
 }
 
+// AppendFloatFormat appends f formatted with strconv's format byte and precision,
+// like 'f' and 2 for fixed point with two decimals.
+func (b *buffer) AppendFloatFormat(f float64, format byte, prec int) {
+	*b = strconv.AppendFloat(*b, f, format, prec, 64)
+}
+
+// AppendNumber appends v if its underlying type is an integer or float, like a
+// named type `type StatusCode int`, which slog stores as KindAny, and which would
+// otherwise be formatted with fmt.  It returns false if v isn't a number.
+func (b *buffer) AppendNumber(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.AppendInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.AppendUint(rv.Uint())
+	case reflect.Float32:
+		*b = strconv.AppendFloat(*b, rv.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		b.AppendFloat(rv.Float())
+	default:
+		return false
+	}
+	return true
+}
+
 func (b *buffer) AppendBool(i bool) {
 	*b = strconv.AppendBool(*b, i)
 }
 
-func (b *buffer) AppendDuration(d time.Duration) {
-	*b = appendDuration(*b, d)
+func (b *buffer) AppendDuration(d time.Duration, opts DurationOptions) {
+	*b = appendDuration(*b, d, opts)
+}
+
+func (b *buffer) AppendISODuration(d time.Duration, opts DurationOptions) {
+	*b = appendISODuration(*b, d, opts)
 }
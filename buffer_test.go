@@ -2,8 +2,11 @@ package console
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -27,13 +30,69 @@ func TestBuffer_Append(t *testing.T) {
 	b.AppendInt(42)
 	b.AppendUint(12)
 	b.Append([]byte("foo"))
-	b.AppendDuration(1 * time.Second)
+	b.AppendDuration(1*time.Second, DurationOptions{})
 	now := time.Now()
 	b.AppendTime(now, time.RFC3339)
 
 	AssertEqual(t, "foobarbaz.truefalse3.144212foo1s"+now.Format(time.RFC3339), b.String())
 }
 
+func TestBuffer_Grow(t *testing.T) {
+	var b buffer
+	b.AppendString("x")
+	AssertEqual(t, 1024, cap(b))
+
+	// doubles
+	b.Append(make([]byte, 1024))
+	AssertEqual(t, 2048, cap(b))
+
+	// grows to fit large appends at once
+	b.Append(make([]byte, 10000))
+	AssertEqual(t, 11025, cap(b))
+
+	// steps are capped
+	b = make(buffer, 0, 4*maxGrowStep)
+	b.Append(make([]byte, 4*maxGrowStep+1))
+	AssertEqual(t, 5*maxGrowStep, cap(b))
+}
+
+func TestBuffer_ResetLarge(t *testing.T) {
+	before := EncoderPoolStats()
+	b := make(buffer, 0, maxBufferSize+1)
+	b.Reset()
+	AssertEqual(t, 0, cap(b))
+	AssertEqual(t, before.Discards+1, EncoderPoolStats().Discards)
+}
+
+func TestBuffer_AppendNumber(t *testing.T) {
+	type code int16
+	type ratio float32
+	type id uint8
+
+	var b buffer
+	for _, v := range []any{code(-404), ratio(0.1), id(7), 2.5, "no", struct{}{}} {
+		if b.AppendNumber(v) {
+			b.AppendByte(' ')
+		}
+	}
+	AssertEqual(t, "-404 0.1 7 2.5 ", b.String())
+
+	b.Reset()
+	b.AppendFloatFormat(3.14159, 'f', 2)
+	AssertEqual(t, "3.14", b.String())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		b.AppendInt(-42)
+		b.AppendUint(42)
+		b.AppendFloat(1.5)
+		b.AppendFloatFormat(1.5, 'e', 3)
+		b.AppendNumber(code(1))
+		b.AppendNumber(ratio(1.5))
+	})
+	AssertEqual(t, 0.0, allocs)
+}
+
 func TestBuffer_WriteTo(t *testing.T) {
 	dest := bytes.Buffer{}
 	var b buffer
@@ -74,6 +133,44 @@ func TestBuffer_WriteTo_Err(t *testing.T) {
 	}
 }
 
+func TestBuffer_WriteTo_Partial(t *testing.T) {
+	var dest bytes.Buffer
+	// accepts at most 2 bytes per write
+	w := writerFunc(func(b []byte) (int, error) {
+		if len(b) > 2 {
+			b = b[:2]
+		}
+		return dest.Write(b)
+	})
+	var b buffer
+	b.AppendString("foobar")
+	n, err := b.WriteTo(w)
+	AssertNoError(t, err)
+	AssertEqual(t, int64(6), n)
+	AssertEqual(t, "foobar", dest.String())
+	AssertZero(t, len(b))
+
+	// stops making progress part way through
+	dest.Reset()
+	w = writerFunc(func(b []byte) (int, error) {
+		if dest.Len() >= 4 {
+			return 0, nil
+		}
+		return dest.Write(b[:2])
+	})
+	b.AppendString("foobar")
+	n, err = b.WriteTo(w)
+	AssertEqual(t, int64(4), n)
+	var swe *ShortWriteError
+	if !errors.As(err, &swe) {
+		t.Fatalf("Expected *ShortWriteError, got %T", err)
+	}
+	AssertEqual(t, ShortWriteError{Written: 4, Len: 6}, *swe)
+	AssertEqual(t, "short write: wrote 4 of 6 bytes", err.Error())
+	// the rest is left in the buffer
+	AssertEqual(t, "ar", b.String())
+}
+
 func BenchmarkBuffer(b *testing.B) {
 	data := []byte("foobarbaz")
 
@@ -95,3 +192,56 @@ func BenchmarkBuffer(b *testing.B) {
 		}
 	})
 }
+
+func TestBuffer_WriteChunksTo(t *testing.T) {
+	var writes []string
+	w := writerFunc(func(b []byte) (int, error) {
+		writes = append(writes, string(b))
+		return len(b), nil
+	})
+	var b buffer
+	b.AppendString("foobarbaz!")
+	n, err := b.WriteChunksTo(w, 3)
+	AssertNoError(t, err)
+	AssertEqual(t, int64(10), n)
+	AssertEqual(t, "foo|bar|baz|!", strings.Join(writes, "|"))
+	AssertZero(t, len(b))
+}
+
+func TestHandler_TrailerChunkSize(t *testing.T) {
+	var writes []string
+	w := writerFunc(func(b []byte) (int, error) {
+		writes = append(writes, string(b))
+		return len(b), nil
+	})
+	dump := strings.Repeat("0123456789\n", 6)
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", TrailerChunkSize: 32})
+	ctx, c := WithCapture(context.Background())
+	logger := slog.New(h)
+
+	logger.InfoContext(ctx, "dump", "small", "a\nb")
+	AssertEqual(t, "dump\n=== small ===\na\nb\n", strings.Join(writes, "|"))
+
+	writes = nil
+	logger.InfoContext(ctx, "dump", "x", 1, "big", dump)
+	want := "dump x=1\n=== big ===\n" + dump + "\n"
+	AssertEqual(t, want, strings.Join(writes, ""))
+	// the line, then the trailer in chunks
+	AssertEqual(t, "dump x=1", writes[0])
+	AssertEqual(t, 4, len(writes))
+	for _, s := range writes[1:] {
+		AssertEqual(t, true, len(s) <= 32)
+	}
+	AssertEqual(t, "dump\n=== small ===\na\nb\n"+want, string(c.Bytes()))
+}
+
+func TestHandler_TrailerChunkSize_SameOutput(t *testing.T) {
+	// attrs rendered straight into the trailer come out the same as when they're moved there
+	render := func(chunk int) string {
+		var buf bytes.Buffer
+		l := slog.New(NewHandler(&buf, &HandlerOptions{HeaderFormat: "%m %a", TrailerChunkSize: chunk, AttrOrder: []string{"g.b"}}))
+		l.With("ctx", "c\nd").WithGroup("g").Info("dump", "a", 1, "dump", strings.Repeat("line\n", 20), "b", "x", "err", errors.New("e\nf"))
+		return buf.String()
+	}
+	AssertEqual(t, render(0), render(8))
+}
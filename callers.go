@@ -0,0 +1,71 @@
+package console
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// writeCallerChain writes the caller chain of a record, starting with the frame at pc, to
+// the multiline trailer, as an indented list, like:
+//
+//	at main.handle (main.go:42)
+//	at main.serve (main.go:30)
+//
+// The chain is found by walking the current stack up to pc, so it's only complete when the
+// record is handled in the goroutine which logged it.  Otherwise, only pc is printed.
+func (e *encoder) writeCallerChain(pc uintptr) {
+	var pcs [64]uintptr
+	n := runtime.Callers(2, pcs[:])
+	chain := pcs[:n]
+	if i := slices.Index(chain, pc); i >= 0 {
+		chain = chain[i:]
+	} else {
+		chain = []uintptr{pc}
+	}
+	limit := e.h.opts.CallerChain
+	if max := e.h.opts.MaxFrames; max > 0 && max < limit {
+		limit = max
+	}
+	frames := runtime.CallersFrames(chain)
+	for printed := 0; printed < limit; {
+		frame, more := frames.Next()
+		if frame.Function == "" {
+			break
+		}
+		if !e.skipFrame(frame) {
+			e.multilineAttrBuf.AppendString("\n  at ")
+			e.withColor(&e.multilineAttrBuf, e.h.opts.Theme.Source, func() {
+				e.writeFrame(&e.multilineAttrBuf, frame)
+			})
+			printed++
+		}
+		if !more {
+			break
+		}
+	}
+}
+
+// StandardSkipFrames are the prefixes of the functions of the Go runtime and testing
+// frames, for HandlerOptions.SkipFrames.
+var StandardSkipFrames = []string{"runtime.", "testing."}
+
+// skipFrame reports whether the frame's function has one of the SkipFrames prefixes.
+func (e *encoder) skipFrame(frame runtime.Frame) bool {
+	for _, prefix := range e.h.opts.SkipFrames {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFrame writes a stack frame, like "main.handle (main.go:42)".
+func (e *encoder) writeFrame(buf *buffer, frame runtime.Frame) {
+	buf.AppendString(frame.Function)
+	buf.AppendString(" (")
+	buf.AppendString(e.normalizePath(trimmedPath(frame.File, cwd, e.h.opts.TruncateSourcePath)))
+	buf.AppendByte(':')
+	buf.AppendInt(int64(frame.Line))
+	buf.AppendByte(')')
+}
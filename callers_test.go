@@ -0,0 +1,48 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+//go:noinline
+func logError(l *slog.Logger) {
+	l.Error("failed", "a", 1)
+}
+
+func TestHandler_CallerChain(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", CallerChain: 2}))
+	logError(l)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	AssertEqual(t, 3, len(lines))
+	AssertEqual(t, "failed a=1", lines[0])
+	AssertEqual(t, true, strings.HasPrefix(lines[1], "  at github.com/ansel1/console-slog.logError (callers_test.go:"))
+	AssertEqual(t, true, strings.HasPrefix(lines[2], "  at github.com/ansel1/console-slog.TestHandler_CallerChain (callers_test.go:"))
+
+	// only for errors
+	buf.Reset()
+	l.Warn("warned")
+	AssertEqual(t, "warned\n", buf.String())
+}
+
+func TestHandler_CallerChainFilters(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(NewHandler(&buf, &HandlerOptions{
+		NoColor:      true,
+		HeaderFormat: "%m %a",
+		CallerChain:  5,
+		SkipFrames:   append([]string{"github.com/ansel1/console-slog.logError"}, StandardSkipFrames...),
+	}))
+	logError(l)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	AssertEqual(t, 2, len(lines))
+	AssertEqual(t, true, strings.HasPrefix(lines[1], "  at github.com/ansel1/console-slog.TestHandler_CallerChainFilters ("))
+
+	buf.Reset()
+	l = slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", CallerChain: 5, MaxFrames: 1}))
+	logError(l)
+	AssertEqual(t, 2, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,73 @@
+package console
+
+import (
+	"context"
+	"sync"
+)
+
+type captureKey struct{}
+
+// Capture collects the lines rendered by Handlers for records logged with a context
+// returned by WithCapture, e.g. to include the log of a single request in an error
+// response.  Lines are captured as plain text, without ANSI escape sequences.  It's
+// safe for concurrent use.
+type Capture struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// WithCapture returns a copy of ctx which tees the lines rendered by Handlers into the
+// returned Capture, as well as writing them to the handler's output:
+//
+//	ctx, capture := console.WithCapture(r.Context())
+//	...
+//	logger.ErrorContext(ctx, "query failed", "err", err)
+//	...
+//	http.Error(w, capture.String(), http.StatusInternalServerError)
+//
+// Only records which pass the handler's level are captured.  Combine with WithMinLevel to
+// capture debug records too.  Only the *Context logging methods pass the context to the
+// handler.
+func WithCapture(ctx context.Context) (context.Context, *Capture) {
+	c := new(Capture)
+	return context.WithValue(ctx, captureKey{}, c), c
+}
+
+// CaptureFromContext returns the Capture added to ctx by WithCapture, or nil.
+func CaptureFromContext(ctx context.Context) *Capture {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(captureKey{}).(*Capture)
+	return c
+}
+
+func (c *Capture) write(line []byte, transient bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = appendStripped(c.buf, line)
+	if transient {
+		c.buf = append(c.buf, '\n')
+	}
+}
+
+// Bytes returns a copy of the captured lines.
+func (c *Capture) Bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.buf...)
+}
+
+// String returns the captured lines.
+func (c *Capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return string(c.buf)
+}
+
+// Reset discards the captured lines.
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = c.buf[:0]
+}
@@ -0,0 +1,36 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestWithCapture(t *testing.T) {
+	var buf bytes.Buffer
+	theme := NewDefaultTheme()
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{Theme: theme, HeaderFormat: "%l %m %a"}))
+
+	ctx, capture := WithCapture(context.Background())
+	logger.InfoContext(context.Background(), "other request")
+	logger.InfoContext(ctx, "start", "id", 1)
+	logger.DebugContext(ctx, "dropped")
+	logger.With("a", 1).ErrorContext(ctx, "failed")
+	logger.DebugContext(WithMinLevel(ctx, slog.LevelDebug), "debug")
+
+	AssertEqual(t, "INF start id=1\nERR failed a=1\nDBG debug\n", capture.String())
+	AssertEqual(t, "INF start id=1\nERR failed a=1\nDBG debug\n", string(capture.Bytes()))
+	// the handler's output is still colored, and has all the lines
+	AssertEqual(t, 4, bytes.Count(buf.Bytes(), []byte("\n")))
+	AssertEqual(t, true, bytes.Contains(buf.Bytes(), []byte(styled("failed", theme.Message))))
+
+	capture.Reset()
+	AssertEqual(t, "", capture.String())
+}
+
+func TestCaptureFromContext(t *testing.T) {
+	AssertEqual(t, (*Capture)(nil), CaptureFromContext(context.Background()))
+	ctx, c := WithCapture(context.Background())
+	AssertEqual(t, c, CaptureFromContext(ctx))
+}
@@ -0,0 +1,90 @@
+package console
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// errorStack returns the stack trace carried by err itself, not by the errors it wraps, or
+// nil.  Errors carry stacks by having a Callers() []uintptr method, like go-errors, or a
+// StackTrace method returning a slice of program counters, like pkg/errors.
+func errorStack(err error) []uintptr {
+	if v := reflect.ValueOf(err); v.Kind() == reflect.Pointer && v.IsNil() {
+		// a typed nil can't have a stack, and its methods may panic
+		return nil
+	}
+	if c, ok := err.(interface{ Callers() []uintptr }); ok {
+		return c.Callers()
+	}
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	st := m.Call(nil)[0]
+	if st.Kind() != reflect.Slice || st.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	pcs := make([]uintptr, st.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(st.Index(i).Uint())
+	}
+	return pcs
+}
+
+// hasErrorStack reports whether err, or any error it wraps, carries a stack trace.
+func hasErrorStack(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if errorStack(err) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCausedBy writes err Java-style: its message and stack, then a "caused by:" section
+// for each wrapped error which carries its own stack.  Frames a cause's stack has in
+// common with the stack before it, and frames past MaxFrames, are elided, like "... 3 more".
+func (e *encoder) writeCausedBy(buf *buffer, err error) {
+	buf.AppendString(err.Error())
+	var prev []uintptr
+	for cause := err; cause != nil; cause = errors.Unwrap(cause) {
+		pcs := errorStack(cause)
+		if pcs == nil {
+			continue
+		}
+		if prev != nil {
+			buf.AppendString("\ncaused by: ")
+			buf.AppendString(cause.Error())
+		}
+		common := 0
+		for common < len(pcs) && common < len(prev) && pcs[len(pcs)-1-common] == prev[len(prev)-1-common] {
+			common++
+		}
+		omitted := common
+		printed := 0
+		frames := runtime.CallersFrames(pcs[:len(pcs)-common])
+		for {
+			frame, more := frames.Next()
+			switch {
+			case frame.Function == "" || e.skipFrame(frame):
+			case e.h.opts.MaxFrames > 0 && printed >= e.h.opts.MaxFrames:
+				omitted++
+			default:
+				buf.AppendString("\n  at ")
+				e.writeFrame(buf, frame)
+				printed++
+			}
+			if !more {
+				break
+			}
+		}
+		if omitted > 0 {
+			buf.AppendString("\n  ... ")
+			buf.AppendString(strconv.Itoa(omitted))
+			buf.AppendString(" more")
+		}
+		prev = pcs
+	}
+}
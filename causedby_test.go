@@ -0,0 +1,123 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type stackError struct {
+	msg   string
+	cause error
+	pcs   []uintptr
+}
+
+func newStackError(msg string, cause error) *stackError {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	return &stackError{msg: msg, cause: cause, pcs: pcs[:n]}
+}
+
+func (e *stackError) Error() string {
+	if e.cause != nil {
+		return e.msg + ": " + e.cause.Error()
+	}
+	return e.msg
+}
+
+func (e *stackError) Unwrap() error      { return e.cause }
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+// frame is like pkg/errors' Frame
+type frame uintptr
+
+type pkgError struct{ pcs []uintptr }
+
+func (e pkgError) Error() string { return "pkg" }
+
+func (e pkgError) StackTrace() []frame {
+	frames := make([]frame, len(e.pcs))
+	for i, pc := range e.pcs {
+		frames[i] = frame(pc)
+	}
+	return frames
+}
+
+//go:noinline
+func failInner() error {
+	return newStackError("inner", nil)
+}
+
+//go:noinline
+func failOuter() error {
+	return newStackError("outer", fmt.Errorf("wrapped: %w", failInner()))
+}
+
+func TestHandler_CausedBy(t *testing.T) {
+	err := failOuter()
+	var out strings.Builder
+	h := NewHandler(&out, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", CausedBy: true})
+	slog.New(h).Error("failed", "err", err)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	AssertEqual(t, "failed", lines[0])
+	AssertEqual(t, "=== err ===", lines[1])
+	AssertEqual(t, "outer: wrapped: inner", lines[2])
+	AssertEqual(t, true, strings.HasPrefix(lines[3], "  at github.com/ansel1/console-slog.failOuter (causedby_test.go:"))
+	AssertEqual(t, true, strings.HasPrefix(lines[4], "  at github.com/ansel1/console-slog.TestHandler_CausedBy (causedby_test.go:"))
+	i := 5
+	for strings.HasPrefix(lines[i], "  at ") {
+		i++
+	}
+	// the wrapped error without a stack gets no section
+	AssertEqual(t, "caused by: inner", lines[i])
+	AssertEqual(t, true, strings.HasPrefix(lines[i+1], "  at github.com/ansel1/console-slog.failInner (causedby_test.go:"))
+	AssertEqual(t, true, strings.HasPrefix(lines[i+2], "  at github.com/ansel1/console-slog.failOuter (causedby_test.go:"))
+	// the frames below failOuter are shared with the outer error
+	AssertEqual(t, true, strings.HasPrefix(lines[i+3], "  ... "))
+	AssertEqual(t, true, strings.HasSuffix(lines[i+3], " more"))
+	AssertEqual(t, i+4, len(lines))
+}
+
+func TestErrorStack(t *testing.T) {
+	pcs := []uintptr{1, 2, 3}
+	AssertEqual(t, 3, len(errorStack(pkgError{pcs})))
+	AssertEqual(t, uintptr(2), errorStack(pkgError{pcs})[1])
+	AssertEqual(t, 0, len(errorStack(errors.New("x"))))
+	AssertEqual(t, false, hasErrorStack(errors.New("x")))
+	AssertEqual(t, true, hasErrorStack(fmt.Errorf("w: %w", pkgError{pcs})))
+	AssertEqual(t, 0, len(errorStack((*pkgError)(nil))))
+	AssertEqual(t, 0, len(errorStack((*stackError)(nil))))
+}
+
+func TestHandler_CausedByOff(t *testing.T) {
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Any("err", newStackError("boom", nil))},
+		want:  "err=boom\n",
+	}.run(t)
+}
+
+func TestHandler_CausedByFilters(t *testing.T) {
+	err := failOuter()
+	var out strings.Builder
+	h := NewHandler(&out, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", CausedBy: true, SkipFrames: StandardSkipFrames})
+	slog.New(h).Error("failed", "err", err)
+	AssertEqual(t, false, strings.Contains(out.String(), "  at runtime."))
+	AssertEqual(t, false, strings.Contains(out.String(), "  at testing."))
+
+	out.Reset()
+	h = NewHandler(&out, &HandlerOptions{NoColor: true, HeaderFormat: "%a", CausedBy: true, MaxFrames: 1})
+	slog.New(h).Error("failed", "err", err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	AssertEqual(t, 7, len(lines))
+	AssertEqual(t, "outer: wrapped: inner", lines[1])
+	AssertEqual(t, true, strings.HasPrefix(lines[2], "  at github.com/ansel1/console-slog.failOuter ("))
+	AssertEqual(t, true, strings.HasPrefix(lines[3], "  ... "))
+	AssertEqual(t, "caused by: inner", lines[4])
+	AssertEqual(t, true, strings.HasPrefix(lines[5], "  at github.com/ansel1/console-slog.failInner ("))
+	AssertEqual(t, true, strings.HasPrefix(lines[6], "  ... "))
+}
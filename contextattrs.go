@@ -0,0 +1,43 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Keys of the attrs added by HandlerOptions.ContextDeadline and ContextCause.
+const (
+	DeadlineKey   = "ctx_remaining"
+	ContextErrKey = "ctx_err"
+)
+
+// addContextAttrs returns the record with the attrs taken from ctx added, as set by
+// ContextDeadline and ContextCause.
+func (h *Handler) addContextAttrs(ctx context.Context, rec slog.Record) slog.Record {
+	if rec.Level < slog.LevelWarn || ctx == nil {
+		return rec
+	}
+	var attrs [2]slog.Attr
+	n := 0
+	if h.opts.ContextDeadline {
+		if deadline, ok := ctx.Deadline(); ok {
+			now := rec.Time
+			if now.IsZero() {
+				now = time.Now()
+			}
+			attrs[n] = slog.Duration(DeadlineKey, deadline.Sub(now))
+			n++
+		}
+	}
+	if h.opts.ContextCause && rec.Level >= slog.LevelError && ctx.Err() != nil {
+		attrs[n] = slog.Any(ContextErrKey, context.Cause(ctx))
+		n++
+	}
+	if n == 0 {
+		return rec
+	}
+	rec = rec.Clone()
+	rec.AddAttrs(attrs[:n]...)
+	return rec
+}
@@ -0,0 +1,64 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHandler_ContextDeadline(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", ContextDeadline: true})
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(1200*time.Millisecond))
+	defer cancel()
+
+	handle := func(ctx context.Context, level slog.Level, at time.Time) {
+		AssertNoError(t, h.Handle(ctx, slog.NewRecord(at, level, "call", 0)))
+	}
+	handle(ctx, slog.LevelWarn, now)
+	handle(ctx, slog.LevelError, now.Add(1500*time.Millisecond))
+	handle(ctx, slog.LevelInfo, now)
+	handle(context.Background(), slog.LevelError, now)
+	AssertEqual(t, "WRN call ctx_remaining=1.2s\n"+
+		"ERR call ctx_remaining=-300ms\n"+
+		"INF call\n"+
+		"ERR call\n", buf.String())
+
+	// off by default
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"})
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(now, slog.LevelError, "call", 0)))
+	AssertEqual(t, "ERR call\n", buf.String())
+}
+
+func TestHandler_ContextCause(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", ContextCause: true})
+	l := slog.New(h)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	l.ErrorContext(ctx, "query failed")
+	cancel(errors.New("shutting down"))
+	l.ErrorContext(ctx, "query failed")
+	l.WarnContext(ctx, "retrying")
+
+	ctx, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+	l.ErrorContext(ctx, "query failed")
+	AssertEqual(t, "ERR query failed\n"+
+		"ERR query failed ctx_err=shutting down\n"+
+		"WRN retrying\n"+
+		"ERR query failed ctx_err=context canceled\n", buf.String())
+
+	// with the deadline
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", ContextCause: true, ContextDeadline: true})
+	now := time.Now()
+	ctx, cancel3 := context.WithDeadline(context.Background(), now.Add(-time.Second))
+	defer cancel3()
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(now, slog.LevelError, "query failed", 0)))
+	AssertEqual(t, "ERR query failed ctx_remaining=-1s ctx_err=context deadline exceeded\n", buf.String())
+}
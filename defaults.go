@@ -0,0 +1,26 @@
+package console
+
+// markDefault marks the DefaultAttrs with the key as replaced.
+func (e *encoder) markDefault(groupPrefix, key string) {
+	for i, d := range e.h.opts.DefaultAttrs {
+		if matchKey(d.Key, groupPrefix, key) {
+			e.defaultsSeen[i] = true
+		}
+	}
+}
+
+// encodeDefaults encodes the DefaultAttrs which haven't been replaced.
+func (e *encoder) encodeDefaults() {
+	if len(e.defaultsSeen) == 0 {
+		return
+	}
+	// defaults aren't in the handler's groups
+	groups := e.groups
+	e.groups = groups[len(groups):]
+	for i, d := range e.h.opts.DefaultAttrs {
+		if !e.defaultsSeen[i] {
+			e.encodeAttr("", d)
+		}
+	}
+	e.groups = groups
+}
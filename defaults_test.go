@@ -0,0 +1,94 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_DefaultAttrs(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "defaults",
+			attrs: []slog.Attr{slog.String("foo", "bar")},
+			want:  "INF msg foo=bar app=api env=dev\n",
+		},
+		{
+			name:  "record overrides",
+			attrs: []slog.Attr{slog.String("env", "prod")},
+			want:  "INF msg env=prod app=api\n",
+		},
+		{
+			name: "context overrides",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("app", "worker")})
+			},
+			attrs: []slog.Attr{slog.String("foo", "bar")},
+			want:  "INF msg app=worker foo=bar env=dev\n",
+		},
+		{
+			name: "grouped keys don't override",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("g")
+			},
+			attrs: []slog.Attr{slog.String("app", "worker")},
+			want:  "INF msg g.app=worker app=api env=dev\n",
+		},
+		{
+			name:  "groups override",
+			attrs: []slog.Attr{slog.Group("app", slog.String("name", "worker"))},
+			want:  "INF msg app.name=worker env=dev\n",
+		},
+	}
+	for _, tt := range tests {
+		tt.msg = "msg"
+		tt.opts = HandlerOptions{
+			NoColor:      true,
+			HeaderFormat: "%l %m %a",
+			DefaultAttrs: []slog.Attr{slog.String("app", "api"), slog.String("env", "dev")},
+		}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_DefaultAttrsHeaders(t *testing.T) {
+	opts := HandlerOptions{
+		NoColor:      true,
+		HeaderFormat: "%l [%[app]h] %m %a",
+		DefaultAttrs: []slog.Attr{slog.String("app", "api")},
+	}
+	handlerTest{
+		opts: opts,
+		msg:  "msg",
+		want: "INF [api] msg\n",
+	}.run(t)
+	handlerTest{
+		opts:  opts,
+		msg:   "msg",
+		attrs: []slog.Attr{slog.String("app", "worker")},
+		want:  "INF [worker] msg\n",
+	}.run(t)
+}
+
+func TestHandler_DefaultAttrsReplaceAttr(t *testing.T) {
+	var groups [][]string
+	handlerTest{
+		opts: HandlerOptions{
+			NoColor:      true,
+			HeaderFormat: "%m %a",
+			DefaultAttrs: []slog.Attr{slog.String("app", "api")},
+			ReplaceAttr: func(g []string, a slog.Attr) slog.Attr {
+				if a.Key == "app" {
+					groups = append(groups, g)
+				}
+				return a
+			},
+		},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithGroup("g")
+		},
+		msg:  "msg",
+		want: "msg app=api\n",
+	}.run(t)
+	AssertEqual(t, 1, len(groups))
+	AssertEqual(t, 0, len(groups[0]))
+}
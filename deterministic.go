@@ -0,0 +1,37 @@
+package console
+
+import (
+	"path"
+	"time"
+)
+
+// normalizeTime replaces t with DeterministicTime if HandlerOptions.Deterministic
+// is set.
+func (e *encoder) normalizeTime(t time.Time) time.Time {
+	if !e.h.opts.Deterministic {
+		return t
+	}
+	return DeterministicTime
+}
+
+// normalizeDuration zeroes durations shorter than the threshold, so timings of
+// fast operations don't churn.
+func (e *encoder) normalizeDuration(d time.Duration) time.Duration {
+	if !e.h.opts.Deterministic {
+		return d
+	}
+	if d < e.h.opts.DeterministicDurationThreshold && d > -e.h.opts.DeterministicDurationThreshold {
+		return 0
+	}
+	return d
+}
+
+// normalizePath reduces a source path to its file name, since absolute paths
+// depend on where the code was checked out.  Source paths always use forward
+// slashes.
+func (e *encoder) normalizePath(p string) string {
+	if !e.h.opts.Deterministic {
+		return p
+	}
+	return path.Base(p)
+}
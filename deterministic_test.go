@@ -0,0 +1,43 @@
+package console
+
+import (
+	"log/slog"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHandler_Deterministic(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, Deterministic: true, TimeFormat: time.RFC3339},
+		time: time.Now(),
+		msg:  "msg",
+		attrs: []slog.Attr{
+			slog.Duration("fast", 250*time.Millisecond),
+			slog.Duration("slow", 3*time.Second),
+			slog.Time("at", time.Now()),
+		},
+		want: "2000-01-01T00:00:00Z INF msg fast=0s slow=3s at=2000-01-01T00:00:00Z\n",
+	}.run(t)
+
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, Deterministic: true, DeterministicDurationThreshold: time.Minute},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Duration("slow", 3*time.Second)},
+		want:  "INF msg slow=0s\n",
+	}.run(t)
+}
+
+func TestHandler_DeterministicSource(t *testing.T) {
+	pc, _, _, _ := runtime.Caller(0)
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, Deterministic: true, AddSource: true, HeaderFormat: "%s %m"},
+		msg:  "msg",
+		pc:   pc,
+		want: "deterministic_test.go:" + strconv.Itoa(f.Line) + " msg\n",
+	}.run(t)
+}
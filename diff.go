@@ -0,0 +1,66 @@
+package console
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// DiffKey is the conventional attribute key for unified diffs.  String
+// values logged under this key are rendered like a [Diff].
+const DiffKey = "diff"
+
+// Diff is a unified-diff formatted string, e.g. the output of "diff -u" or
+// "git diff".  When logged as an attribute value, each line is colored
+// according to its prefix: lines starting with "+" use Theme.DiffInsert, lines
+// starting with "-" use Theme.DiffDelete, and hunk headers ("@@") use
+// Theme.DiffHunk.  Diffs are almost always multiline, so they are normally
+// printed in the multiline section at the end of the log line.
+//
+//	logger.Info("config changed", "changes", console.Diff(unified))
+type Diff string
+
+// diffValue returns the diff text if the attribute should be rendered
+// as a diff: either the value is a Diff, or the key is DiffKey and
+// the value is a string.
+func diffValue(a slog.Attr) (string, bool) {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if d, ok := a.Value.Any().(Diff); ok {
+			return string(d), true
+		}
+	case slog.KindString:
+		if a.Key == DiffKey {
+			return a.Value.String(), true
+		}
+	}
+	return "", false
+}
+
+func (e *encoder) writeDiff(buf *buffer, diff string) {
+	for len(diff) > 0 {
+		line := diff
+		rest := ""
+		if i := strings.IndexByte(diff, '\n'); i >= 0 {
+			line, rest = diff[:i], diff[i:]
+		}
+
+		var style ANSIMod
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			style = e.h.opts.Theme.Header
+		case strings.HasPrefix(line, "@@"):
+			style = e.h.opts.Theme.DiffHunk
+		case strings.HasPrefix(line, "+"):
+			style = e.h.opts.Theme.DiffInsert
+		case strings.HasPrefix(line, "-"):
+			style = e.h.opts.Theme.DiffDelete
+		}
+		e.writeColoredString(buf, line, style)
+
+		if rest != "" {
+			buf.AppendByte('\n')
+			rest = rest[1:]
+		}
+		diff = rest
+	}
+}
@@ -0,0 +1,48 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_Diff(t *testing.T) {
+	diff := "--- a/config.yml\n+++ b/config.yml\n@@ -1,2 +1,2 @@\n name: app\n-replicas: 1\n+replicas: 3"
+	theme := NewDefaultTheme()
+
+	tests := []handlerTest{
+		{
+			name:  "diff key",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.String("diff", diff)},
+			want:  "INF reconciled\n=== diff ===\n" + diff + "\n",
+		},
+		{
+			name:  "diff type",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("changes", Diff(diff))},
+			want:  "INF reconciled\n=== changes ===\n" + diff + "\n",
+		},
+		{
+			name:  "colored",
+			opts:  HandlerOptions{HeaderFormat: "%m %a", Theme: theme},
+			attrs: []slog.Attr{slog.Any("changes", Diff("@@ -1 +1 @@\n-old\n+new\n same"))},
+			want: styled("reconciled", theme.Message) + "\n" +
+				styled("=== changes ===\n", theme.AttrKey) +
+				styled("@@ -1 +1 @@", theme.DiffHunk) + "\n" +
+				styled("-old", theme.DiffDelete) + "\n" +
+				styled("+new", theme.DiffInsert) + "\n" +
+				" same\n",
+		},
+		{
+			name:  "non-string diff key",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Int("diff", 3)},
+			want:  "INF reconciled diff=3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "reconciled"
+		t.Run(tt.name, tt.run)
+	}
+}
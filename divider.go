@@ -0,0 +1,50 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+)
+
+// dividerKey is the key of the attr marking divider records.
+const dividerKey = "divider"
+
+// dividerValue marks a record as a divider.  Other handlers just print it as
+// an ordinary attr.
+type dividerValue struct{}
+
+func (dividerValue) String() string { return "true" }
+
+// Divider logs a full-width rule, with title embedded in it, to visually separate phases of
+// long-running programs:
+//
+//	console.Divider(logger, "Phase 2: migration")
+//
+// prints:
+//
+//	── Phase 2: migration ──────────────────────────────────────────────────────────
+//
+// The divider is logged at LevelInfo, and uses the Header style of the Theme.  The width of
+// the rule is HandlerOptions.Width, or the width set with Handler.SetWidth.  Handlers other
+// than this package's Handler print an ordinary record with title as the message.
+func Divider(logger *slog.Logger, title string) {
+	logger.LogAttrs(context.Background(), slog.LevelInfo, title, slog.Any(dividerKey, dividerValue{}))
+}
+
+func (e *encoder) writeDivider(title string) {
+	const rule = "─"
+	width := e.h.width()
+	e.withColor(&e.buf, e.h.opts.Theme.Header, func() {
+		if title == "" {
+			for i := 0; i < width; i++ {
+				e.buf.AppendString(rule)
+			}
+			return
+		}
+		e.buf.AppendString(rule + rule + " ")
+		e.buf.AppendString(title)
+		e.buf.AppendByte(' ')
+		for n := 4 + DisplayWidth(title); n < width; n++ {
+			e.buf.AppendString(rule)
+		}
+	})
+}
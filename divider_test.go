@@ -0,0 +1,45 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDivider(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, Width: 20}))
+
+	Divider(logger, "Phase 2")
+	AssertEqual(t, "── Phase 2 ─────────\n", buf.String())
+
+	buf.Reset()
+	Divider(logger.With("foo", "bar").WithGroup("g"), "")
+	AssertEqual(t, strings.Repeat("─", 20)+"\n", buf.String())
+
+	buf.Reset()
+	Divider(logger, "a title which is longer than the width")
+	AssertEqual(t, "── a title which is longer than the width \n", buf.String())
+
+	buf.Reset()
+	Divider(slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, Level: slog.LevelWarn})), "hidden")
+	AssertEqual(t, "", buf.String())
+
+	// resized
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, Width: 20})
+	logger = slog.New(h).With("foo", "bar")
+	h.SetWidth(6)
+	buf.Reset()
+	Divider(logger, "")
+	AssertEqual(t, strings.Repeat("─", 6)+"\n", buf.String())
+	h.SetWidth(0)
+	buf.Reset()
+	Divider(logger, "")
+	AssertEqual(t, strings.Repeat("─", 20)+"\n", buf.String())
+
+	theme := NewDefaultTheme()
+	buf.Reset()
+	Divider(slog.New(NewHandler(&buf, &HandlerOptions{Width: 8, Theme: theme})), "x")
+	AssertEqual(t, styled("── x ───", theme.Header)+"\n", buf.String())
+}
@@ -2,11 +2,101 @@ package console
 
 import "time"
 
+// DurationFormat selects how duration values are printed.
+type DurationFormat int
+
+const (
+	// DurationGo prints durations like time.Duration.String, e.g. "2h3m4.5s".
+	DurationGo DurationFormat = iota
+	// DurationISO8601 prints durations in ISO 8601 form, e.g. "PT2H3M4.5S", for
+	// interop with systems which parse them.
+	DurationISO8601
+)
+
+// appendISODuration appends the duration in ISO 8601 form, like "PT2H3M4.5S".
+// Zero units are omitted, and hours aren't converted to days, since days can be
+// different lengths.  The zero duration formats as "PT0S", and negative durations
+// have a leading "-", as in Java and XML Schema, unless opts say otherwise.
+func appendISODuration(dst []byte, d time.Duration, opts DurationOptions) []byte {
+	// Largest time is -PT2562047H47M16.854775808S
+	var buf [32]byte
+	w := len(buf)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+	if u == 0 {
+		if opts.ZeroDash {
+			return append(dst, '-')
+		}
+		return append(dst, "PT0S"...)
+	}
+
+	frac := u % uint64(time.Second)
+	u /= uint64(time.Second)
+	secs, mins, hours := u%60, u/60%60, u/3600
+
+	if secs != 0 || frac != 0 {
+		w--
+		buf[w] = 'S'
+		w, _ = fmtFrac(buf[:w], frac, 9)
+		w = fmtInt(buf[:w], secs)
+	}
+	if mins != 0 {
+		w--
+		buf[w] = 'M'
+		w = fmtInt(buf[:w], mins)
+	}
+	if hours != 0 {
+		w--
+		buf[w] = 'H'
+		w = fmtInt(buf[:w], hours)
+	}
+	w -= 2
+	copy(buf[w:], "PT")
+	return appendSigned(dst, buf[w:], neg, opts)
+}
+
+// DurationUnits is the largest unit used to print durations.
+type DurationUnits int
+
+const (
+	// DurationUnitsHours uses hours as the largest unit, like time.Duration.String,
+	// e.g. "49h0m1s".
+	DurationUnitsHours DurationUnits = iota
+	// DurationUnitsDays adds days, as 24 hours, e.g. "2d1h0m1s".
+	DurationUnitsDays
+	// DurationUnitsWeeks adds weeks, as 7 days, and days, e.g. "1w2d0h0m0s".
+	DurationUnitsWeeks
+)
+
+// DurationOptions are options for formatting durations with AppendDuration.
+type DurationOptions struct {
+	// Units is the largest unit used.  Defaults to hours.
+	Units DurationUnits
+
+	// NegativeParens prints negative durations in parentheses, like "(1m30s)", instead
+	// of with a leading minus, like an accounting ledger.
+	NegativeParens bool
+
+	// ZeroDash prints the zero duration as "-", instead of "0s", so zeros stand out
+	// less in columns of timings.
+	ZeroDash bool
+}
+
+// AppendDuration appends the duration to dst, formatted like time.Duration.String, but
+// with the units set in opts, and without allocating.
+func AppendDuration(dst []byte, d time.Duration, opts DurationOptions) []byte {
+	return appendDuration(dst, d, opts)
+}
+
 // appendDuration appends a string representing the duration in the form "72h3m0.5s".
 // Leading zero units are omitted. As a special case, durations less than one
 // second format use a smaller unit (milli-, micro-, or nanoseconds) to ensure
 // that the leading digit is non-zero. The zero duration formats as 0s.
-func appendDuration(dst []byte, d time.Duration) []byte {
+func appendDuration(dst []byte, d time.Duration, opts DurationOptions) []byte {
 	// Largest time is 2540400h10m10.000000000s
 	var buf [32]byte
 	w := len(buf)
@@ -26,6 +116,9 @@ func appendDuration(dst []byte, d time.Duration) []byte {
 		w--
 		switch {
 		case u == 0:
+			if opts.ZeroDash {
+				return append(dst, '-')
+			}
 			return append(dst, "0s"...)
 		case u < uint64(time.Microsecond):
 			// print nanoseconds
@@ -62,26 +155,56 @@ func appendDuration(dst []byte, d time.Duration) []byte {
 			u /= 60
 
 			// u is now integer hours
-			// Stop at hours because days can be different lengths.
+			// By default, stop at hours because days can be different lengths.
 			if u > 0 {
 				w--
 				buf[w] = 'h'
-				w = fmtInt(buf[:w], u%24)
-				u /= 24
+				if opts.Units < DurationUnitsDays {
+					w = fmtInt(buf[:w], u)
+					u = 0
+				} else {
+					w = fmtInt(buf[:w], u%24)
+					u /= 24
+				}
+				// u is now integer days
 				if u > 0 {
 					w--
 					buf[w] = 'd'
+					if opts.Units < DurationUnitsWeeks {
+						w = fmtInt(buf[:w], u)
+						u = 0
+					} else {
+						w = fmtInt(buf[:w], u%7)
+						u /= 7
+					}
+				}
+				// u is now integer weeks
+				if u > 0 {
+					w--
+					buf[w] = 'w'
 					w = fmtInt(buf[:w], u)
 				}
 			}
 		}
 	}
 
-	if neg {
-		w--
-		buf[w] = '-'
+	return appendSigned(dst, buf[w:], neg, opts)
+}
+
+// appendSigned appends the formatted magnitude of a duration, with a leading "-"
+// if it's negative, or in parentheses if opts.NegativeParens is set.
+func appendSigned(dst, abs []byte, neg bool, opts DurationOptions) []byte {
+	switch {
+	case !neg:
+		return append(dst, abs...)
+	case opts.NegativeParens:
+		dst = append(dst, '(')
+		dst = append(dst, abs...)
+		return append(dst, ')')
+	default:
+		dst = append(dst, '-')
+		return append(dst, abs...)
 	}
-	return append(dst, buf[w:]...)
 }
 
 // fmtFrac formats the fraction of v/10**prec (e.g., ".12345") into the
@@ -2,7 +2,10 @@ package console
 
 import (
 	"bytes"
+	"log/slog"
+	"math"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,12 +26,94 @@ func TestDuration(t *testing.T) {
 
 	b := [4096]byte{}
 	for _, tm := range times {
-		bd := appendDuration(b[:0], tm)
+		bd := appendDuration(b[:0], tm, DurationOptions{})
 		AssertEqual(t, tm.String(), string(bd))
 	}
 
-	bd := appendDuration(b[:0], 49*time.Hour+1*time.Second)
-	AssertEqual(t, "2d1h0m1s", string(bd))
+	long := 16*24*time.Hour + time.Hour + time.Second
+	AssertEqual(t, long.String(), string(appendDuration(b[:0], long, DurationOptions{})))
+	AssertEqual(t, "16d1h0m1s", string(appendDuration(b[:0], long, DurationOptions{Units: DurationUnitsDays})))
+	AssertEqual(t, "2w2d1h0m1s", string(AppendDuration(b[:0], long, DurationOptions{Units: DurationUnitsWeeks})))
+	AssertEqual(t, "-1w0d0h0m0s", string(AppendDuration(b[:0], -7*24*time.Hour, DurationOptions{Units: DurationUnitsWeeks})))
+	AssertEqual(t, "1d0h0m0s", string(AppendDuration(b[:0], 24*time.Hour, DurationOptions{Units: DurationUnitsWeeks})))
+}
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{time.Nanosecond, "PT0.000000001S"},
+		{1500 * time.Millisecond, "PT1.5S"},
+		{2*time.Hour + 3*time.Minute + 4*time.Second, "PT2H3M4S"},
+		{2*time.Hour + time.Second, "PT2H1S"},
+		{49 * time.Hour, "PT49H"},
+		{-90 * time.Second, "-PT1M30S"},
+		{time.Duration(-1 << 63), "-PT2562047H47M16.854775808S"},
+	}
+	for _, tt := range tests {
+		AssertEqual(t, tt.want, string(appendISODuration(nil, tt.d, DurationOptions{})))
+	}
+}
+
+func TestHandler_DurationFormat(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Duration("elapsed", 90*time.Second),
+		slog.Group("req", slog.Duration("timeout", 2*time.Hour)),
+	}
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, DurationFormat: DurationISO8601},
+		msg:   "msg",
+		attrs: attrs,
+		want:  "INF msg elapsed=PT1M30S req.timeout=PT2H\n",
+	}.run(t)
+
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, ISO8601DurationKeys: []string{"req.timeout", "ttl"}, HeaderFormat: "%l %[ttl]h %m %a"},
+		msg:   "msg",
+		attrs: append(attrs, slog.Duration("ttl", time.Minute)),
+		want:  "INF PT1M msg elapsed=1m30s req.timeout=PT2H\n",
+	}.run(t)
+}
+
+func TestDurationSignOptions(t *testing.T) {
+	opts := DurationOptions{NegativeParens: true, ZeroDash: true}
+	AssertEqual(t, "(1m30s)", string(AppendDuration(nil, -90*time.Second, opts)))
+	AssertEqual(t, "(1.5ms)", string(AppendDuration(nil, -1500*time.Microsecond, opts)))
+	AssertEqual(t, "1m30s", string(AppendDuration(nil, 90*time.Second, opts)))
+	AssertEqual(t, "-", string(AppendDuration(nil, 0, opts)))
+	AssertEqual(t, "(PT1M30S)", string(appendISODuration(nil, -90*time.Second, opts)))
+	AssertEqual(t, "-", string(appendISODuration(nil, 0, opts)))
+	AssertEqual(t, "(2562047h47m16.854775808s)", string(AppendDuration(nil, time.Duration(-1<<63), opts)))
+}
+
+func TestHandler_DurationSignOptions(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, DurationNegativeParens: true, DurationZeroDash: true, HeaderFormat: "%l %[skew]h %m %a"},
+		msg:  "msg",
+		attrs: []slog.Attr{
+			slog.Duration("skew", -2*time.Second),
+			slog.Duration("wait", 0),
+			slog.Duration("drift", -time.Millisecond),
+		},
+		want: "INF (2s) msg wait=- drift=(1ms)\n",
+	}.run(t)
+}
+
+func TestHandler_DurationUnits(t *testing.T) {
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, DurationUnits: DurationUnitsDays},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Duration("uptime", 49*time.Hour)},
+		want:  "INF msg uptime=2d1h0m0s\n",
+	}.run(t)
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Duration("uptime", 49*time.Hour)},
+		want:  "INF msg uptime=49h0m0s\n",
+	}.run(t)
 }
 
 func BenchmarkDuration(b *testing.B) {
@@ -47,8 +132,32 @@ func BenchmarkDuration(b *testing.B) {
 		w := slices.Grow(buffer{}, 2048)
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			w.AppendDuration(d)
+			w.AppendDuration(d, DurationOptions{})
 			w.Reset()
 		}
 	})
 }
+
+func FuzzAppendDuration(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(1))
+	f.Add(int64(-1500 * time.Millisecond))
+	f.Add(int64(math.MaxInt64))
+	f.Add(int64(math.MinInt64))
+	f.Fuzz(func(t *testing.T, n int64) {
+		d := time.Duration(n)
+		// the default options print like time.Duration.String
+		if got := string(AppendDuration(nil, d, DurationOptions{})); got != d.String() {
+			t.Fatalf("AppendDuration(%d) = %q, want %q", n, got, d.String())
+		}
+		for _, units := range []DurationUnits{DurationUnitsDays, DurationUnitsWeeks} {
+			got := string(AppendDuration([]byte("x"), d, DurationOptions{Units: units, NegativeParens: true}))
+			if !strings.HasPrefix(got, "x") || len(got) == 1 {
+				t.Fatalf("AppendDuration(%d, %v) = %q", n, units, got)
+			}
+			if neg := strings.HasPrefix(got, "x("); neg != (d < 0) || neg != strings.HasSuffix(got, ")") {
+				t.Fatalf("AppendDuration(%d, %v) = %q, wrong parens", n, units, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,312 @@
+// Package elasticsearch posts logs to the bulk API of Elasticsearch or OpenSearch, as
+// JSON documents built with the same attr pipeline as the console handler.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	console "github.com/ansel1/console-slog"
+	"github.com/ansel1/console-slog/internal/batch"
+)
+
+// Options are options for a Sink.
+type Options struct {
+	// URL is the base URL of the cluster, like "http://localhost:9200".  Documents are
+	// posted to URL + "/_bulk".
+	URL string
+
+	// Index is the index, or data stream, documents are created in.
+	Index string
+
+	// BatchSize is the maximum number of documents posted at once.  Defaults to 500.
+	BatchSize int
+
+	// BatchWait is the longest time a document waits before it's posted.  Defaults to 1s.
+	BatchWait time.Duration
+
+	// Client is used to post.  Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Header is added to each request, e.g. for authentication.
+	Header http.Header
+
+	// HandlerOptions configure the minimum level of records posted, and ReplaceAttr, which
+	// rewrites attrs the same way it does for the console handler.
+	HandlerOptions *console.HandlerOptions
+
+	// OnError is called with errors from posts made in the background.
+	OnError func(error)
+}
+
+// Sink batches records as NDJSON, and posts them to the bulk API.
+//
+// Each record becomes a document with "@timestamp", "level" and "message" fields, and
+// a field for each attr.  Groups become nested objects.
+type Sink struct {
+	opts     Options
+	resolver *console.Handler
+	action   []byte
+	batcher  *batch.Batcher[[]byte]
+}
+
+// New returns a Sink posting to the cluster at opts.URL.  Close the sink before exiting, to
+// post pending documents.
+func New(opts Options) *Sink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.BatchWait <= 0 {
+		opts.BatchWait = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	var hopts console.HandlerOptions
+	if opts.HandlerOptions != nil {
+		hopts = *opts.HandlerOptions
+	}
+	hopts.NoColor = true
+	// the handlers only render into io.Discard, and are never closed
+	hopts.Banner, hopts.IdleMarker, hopts.PrintSummary = nil, 0, false
+
+	action, _ := json.Marshal(map[string]any{"create": map[string]string{"_index": opts.Index}})
+	s := &Sink{
+		opts:     opts,
+		resolver: console.NewHandler(io.Discard, &hopts),
+		action:   append(action, '\n'),
+	}
+	s.batcher = batch.New(opts.BatchSize, opts.BatchWait, s.post, opts.OnError)
+	return s
+}
+
+// Middleware returns a console.Middleware which posts records to the bulk API, as well as
+// passing them to the next handler:
+//
+//	sink := elasticsearch.New(elasticsearch.Options{URL: "http://localhost:9200", Index: "logs-app-default"})
+//	defer sink.Close()
+//	logger := slog.New(console.Chain(console.NewHandler(os.Stderr, nil), sink.Middleware()))
+func (s *Sink) Middleware() console.Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &handler{next: next, sink: s, resolver: s.resolver}
+	}
+}
+
+// Flush posts pending documents, and waits for the post to finish.
+func (s *Sink) Flush() error {
+	return s.batcher.Flush()
+}
+
+// Close stops posting in the background, and posts pending documents.
+func (s *Sink) Close() error {
+	return s.batcher.Close()
+}
+
+type handler struct {
+	next     slog.Handler
+	sink     *Sink
+	resolver *console.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.resolver.Enabled(ctx, l) || h.next.Enabled(ctx, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if h.resolver.Enabled(ctx, r.Level) {
+		h.sink.batcher.Add(h.sink.document(r, h.resolver.ResolveAttrs(r)))
+	}
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.resolver = h.resolver.WithAttrs(attrs).(*console.Handler)
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.resolver = h.resolver.WithGroup(name).(*console.Handler)
+	return &h2
+}
+
+// Flush flushes the next handler, if it has a Flush method.  Documents are
+// flushed with Sink.Flush.
+func (h *handler) Flush() error {
+	if f, ok := h.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// document returns the record as a JSON document, followed by a newline.
+func (s *Sink) document(r slog.Record, attrs []slog.Attr) []byte {
+	// ReplaceAttr sees the builtins with their usual keys, like in the console handler,
+	// but they're written with the field names conventional in Elasticsearch
+	builtins := [...]struct {
+		field string
+		attr  slog.Attr
+	}{
+		{"@timestamp", slog.Time(slog.TimeKey, r.Time)},
+		{"level", slog.Any(slog.LevelKey, r.Level)},
+		{"message", slog.String(slog.MessageKey, r.Message)},
+	}
+
+	b := []byte{'{'}
+	replace := s.resolver.Options().ReplaceAttr
+	for _, f := range builtins {
+		a := f.attr
+		if replace != nil {
+			a = replace(nil, a)
+			a.Value = a.Value.Resolve()
+			if a.Equal(slog.Attr{}) {
+				continue
+			}
+		}
+		a.Key = f.field
+		b = appendAttr(b, a)
+	}
+	for _, a := range attrs {
+		b = appendAttr(b, a)
+	}
+	return append(trimComma(b), '}', '\n')
+}
+
+// appendAttr appends a as a JSON field, followed by a comma.
+func appendAttr(b []byte, a slog.Attr) []byte {
+	b = appendString(b, a.Key)
+	b = append(b, ':')
+	b = appendValue(b, a.Value)
+	return append(b, ',')
+}
+
+func appendValue(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendString(b, v.String())
+	case slog.KindInt64, slog.KindUint64, slog.KindBool:
+		return append(b, v.String()...)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return appendString(b, v.String())
+		}
+		return append(b, v.String()...)
+	case slog.KindDuration:
+		// like slog's JSONHandler, durations are nanoseconds
+		return fmt.Appendf(b, "%d", v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return appendString(b, v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		b = append(b, '{')
+		for _, a := range v.Group() {
+			b = appendAttr(b, a)
+		}
+		return append(trimComma(b), '}')
+	}
+	switch x := v.Any().(type) {
+	case error:
+		if _, ok := x.(json.Marshaler); !ok {
+			return appendString(b, x.Error())
+		}
+	case []byte:
+		return appendString(b, string(x))
+	}
+	j, err := json.Marshal(v.Any())
+	if err != nil {
+		return appendString(b, fmt.Sprintf("%+v", v.Any()))
+	}
+	return append(b, j...)
+}
+
+func trimComma(b []byte) []byte {
+	if b[len(b)-1] == ',' {
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	j, _ := json.Marshal(s)
+	return append(b, j...)
+}
+
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// post sends a batch of documents to the bulk API.
+func (s *Sink) post(docs [][]byte) error {
+	var body bytes.Buffer
+	for _, d := range docs {
+		body.Write(s.action)
+		body.Write(d)
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(s.opts.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("elasticsearch: bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("elasticsearch: bulk request failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("elasticsearch: invalid bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	var failed int
+	var first error
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Error == nil {
+				continue
+			}
+			failed++
+			if first == nil {
+				first = fmt.Errorf("%d %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+			}
+		}
+	}
+	if first == nil {
+		first = errors.New("unknown error")
+	}
+	return fmt.Errorf("elasticsearch: %d of %d documents failed: %w", failed, len(docs), first)
+}
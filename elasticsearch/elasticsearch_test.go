@@ -0,0 +1,265 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	console "github.com/ansel1/console-slog"
+)
+
+type server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	lines    []string
+	requests int
+	response string
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{response: `{"errors":false,"items":[]}`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("unexpected content type %s", ct)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			s.lines = append(s.lines, sc.Text())
+		}
+		_, _ = w.Write([]byte(s.response))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSink(t *testing.T) {
+	srv := newServer(t)
+	sink := New(Options{
+		URL:       srv.URL + "/",
+		Index:     "logs",
+		BatchWait: time.Hour,
+		HandlerOptions: &console.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				switch {
+				case a.Key == slog.TimeKey:
+					return slog.Time(a.Key, a.Value.Time().UTC())
+				case a.Key == "password":
+					return slog.String(a.Key, "***")
+				}
+				return a
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+	h := console.Chain(console.NewHandler(&buf, &console.HandlerOptions{NoColor: true, HeaderFormat: "%m %a"}), sink.Middleware())
+	logger := slog.New(h).With("service", "api")
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))
+	r := slog.NewRecord(ts, slog.LevelWarn, "login", 0)
+	r.AddAttrs(
+		slog.String("password", "hunter2"),
+		slog.Duration("elapsed", time.Millisecond),
+		slog.Float64("nan", math.NaN()),
+		slog.Any("err", errors.New("bad")),
+		slog.Any("tags", []string{"a", "b"}),
+	)
+	if err := logger.WithGroup("req").Handler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("dropped")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "login service=api req.password=hunter2 req.elapsed=1ms req.nan=NaN req.err=bad req.tags=[a b]\n"; got != want {
+		t.Errorf("expected console output %q, got %q", want, got)
+	}
+	want := []string{
+		`{"create":{"_index":"logs"}}`,
+		`{"@timestamp":"2024-01-02T02:04:05Z","level":"WARN","message":"login","service":"api","req":{"password":"***","elapsed":1000000,"nan":"NaN","err":"bad","tags":["a","b"]}}`,
+	}
+	if strings.Join(srv.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(srv.lines, "\n"))
+	}
+}
+
+func TestSink_ReplaceBuiltins(t *testing.T) {
+	srv := newServer(t)
+	sink := New(Options{
+		URL:       srv.URL,
+		BatchWait: time.Hour,
+		HandlerOptions: &console.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+	})
+	slog.New(sink.Middleware()(console.NewHandler(&bytes.Buffer{}, nil))).Info("hello", slog.Group("empty"))
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.lines) != 2 || srv.lines[1] != `{"message":"hello"}` {
+		t.Errorf("unexpected documents %q", srv.lines)
+	}
+}
+
+func TestSink_Errors(t *testing.T) {
+	srv := newServer(t)
+	srv.response = `{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`
+	sink := New(Options{URL: srv.URL, BatchWait: time.Hour})
+	logger := slog.New(sink.Middleware()(console.NewHandler(&bytes.Buffer{}, nil)))
+	logger.Info("one")
+	logger.Info("two")
+	err := sink.Flush()
+	if err == nil || err.Error() != "elasticsearch: 1 of 2 documents failed: 400 mapper_parsing_exception: failed to parse" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// field is a leaf of a parsed document, with the keys leading to it.
+type field struct {
+	path  []string
+	value any
+}
+
+// readFields reads the JSON value at the decoder, appending its leaves to fields in
+// order, so duplicate keys aren't lost as they would be in a map.
+func readFields(dec *json.Decoder, path []string, fields []field) ([]field, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return append(fields, field{slices.Clone(path), tok}), nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		fields, err = readFields(dec, append(path, key.(string)), fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = dec.Token()
+	return fields, err
+}
+
+func FuzzSink_Document(f *testing.F) {
+	f.Add("k", "v", int64(1), 1.5)
+	f.Add("", "multi\nline", int64(-5), math.NaN())
+	f.Add("a.b", "\x1b[31mred\x1b[0m", int64(0), math.Inf(-1))
+	f.Add("quote\"d", "back\\slash ", int64(math.MinInt64), 1e300)
+	f.Add("message", "\xff\xfe", int64(1<<40), -0.0)
+	f.Fuzz(func(t *testing.T, key, value string, n int64, x float64) {
+		sink := New(Options{})
+		defer sink.Close()
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+		r := slog.NewRecord(ts, slog.LevelInfo, value, 0)
+		r.AddAttrs(slog.String(key, value), slog.Int64("n", n), slog.Float64("x", x), slog.Group("g", slog.String(key, value)))
+		doc := sink.document(r, sink.resolver.ResolveAttrs(r))
+
+		if bytes.Count(doc, []byte("\n")) != 1 || doc[len(doc)-1] != '\n' {
+			t.Fatalf("document isn't one line: %q", doc)
+		}
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		dec.UseNumber()
+		fields, err := readFields(dec, nil, nil)
+		if err != nil {
+			t.Fatalf("document doesn't parse: %v: %q", err, doc)
+		}
+
+		// strings come back with invalid UTF-8 replaced, like encoding/json does
+		str := func(s string) string {
+			j, _ := json.Marshal(s)
+			_ = json.Unmarshal(j, &s)
+			return s
+		}
+		want := []field{
+			{[]string{"@timestamp"}, ts.Format(time.RFC3339Nano)},
+			{[]string{"level"}, "INFO"},
+			{[]string{"message"}, str(value)},
+			{[]string{str(key)}, str(value)},
+			{[]string{"n"}, n},
+			{[]string{"x"}, x},
+			{[]string{"g", str(key)}, str(value)},
+		}
+		if len(fields) != len(want) {
+			t.Fatalf("expected %d fields, got %d: %q", len(want), len(fields), doc)
+		}
+		for i, w := range want {
+			got := fields[i]
+			if !slices.Equal(w.path, got.path) {
+				t.Fatalf("field %d: expected key %q, got %q: %q", i, w.path, got.path, doc)
+			}
+			var ok bool
+			switch wv := w.value.(type) {
+			case int64:
+				num, _ := got.value.(json.Number)
+				gv, err := num.Int64()
+				ok = err == nil && gv == wv
+			case float64:
+				if math.IsNaN(wv) || math.IsInf(wv, 0) {
+					ok = got.value == slog.Float64Value(wv).String()
+					break
+				}
+				num, _ := got.value.(json.Number)
+				gv, err := num.Float64()
+				ok = err == nil && gv == wv
+			default:
+				ok = got.value == wv
+			}
+			if !ok {
+				t.Fatalf("field %q: expected %v, got %v: %q", w.path, w.value, got.value, doc)
+			}
+		}
+	})
+}
+
+func TestSink_NoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		sink := New(Options{
+			URL: "http://localhost",
+			HandlerOptions: &console.HandlerOptions{
+				IdleMarker:   time.Minute,
+				Banner:       &console.Banner{Name: "app"},
+				PrintSummary: true,
+			},
+		})
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// goroutines may take a moment to exit
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected at most %d goroutines, got %d", before, n)
+	}
+}
@@ -3,7 +3,11 @@ package console
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -15,31 +19,76 @@ import (
 
 var encoderPool = &sync.Pool{
 	New: func() any {
-		e := new(encoder)
-		e.groups = make([]string, 0, 10)
-		e.buf = make(buffer, 0, 1024)
-		e.attrBuf = make(buffer, 0, 1024)
-		e.multilineAttrBuf = make(buffer, 0, 1024)
-		e.headerAttrs = make([]slog.Attr, 0, 5)
-		return e
+		poolStats.news.Add(1)
+		return allocEncoder()
 	},
 }
 
+func allocEncoder() *encoder {
+	e := new(encoder)
+	e.groups = make([]string, 0, 10)
+	e.buf = make(buffer, 0, 1024)
+	e.attrBuf = make(buffer, 0, 1024)
+	e.multilineAttrBuf = make(buffer, 0, 1024)
+	e.headerAttrs = make([]slog.Attr, 0, 5)
+	return e
+}
+
 type encoder struct {
 	h                              *Handler
 	buf, attrBuf, multilineAttrBuf buffer
 	groups                         []string
 	headerAttrs                    []slog.Attr
+	// divider is set if the record is a divider created by Divider
+	divider bool
+	// transient is set if the record has the TransientKey attr set to true
+	transient bool
+	// idleMarker is set for IdleMarker lines, which don't reset the idle time
+	idleMarker bool
+	// streamTrailer is set if the trailer is written from multilineAttrBuf after buf,
+	// in chunks, see HandlerOptions.TrailerChunkSize
+	streamTrailer bool
+	// time of the record
+	time time.Time
+	// formatting of the value being written, chosen by its key
+	valueFormat valueFormat
+	// keys of the "{key}" placeholders in the message, and the attrs captured for them
+	placeholders     []string
+	placeholderAttrs []slog.Attr
+	// recordAttrs is set while the record's own attrs are encoded, which are the only
+	// ones substituted into placeholders
+	recordAttrs bool
+	// attrs matching HandlerOptions.AttrOrder, which are printed first
+	orderBuf   buffer
+	orderAttrs []orderedAttr
+	// the rendered value of the error attr printed by the %e verb
+	errorBuf      buffer
+	errorCaptured bool
+	// marks the DefaultAttrs which have been replaced
+	defaultsSeen []bool
+	// the values being expanded by writeYAMLReflect, to detect cycles
+	yamlPath []yamlRef
 }
 
 func newEncoder(h *Handler) *encoder {
-	e := encoderPool.Get().(*encoder)
+	var e *encoder
+	if h.opts.DisablePooling {
+		e = allocEncoder()
+	} else {
+		e = encoderPool.Get().(*encoder)
+		poolStats.gets.Add(1)
+	}
 	e.h = h
 	if h.opts.ReplaceAttr != nil {
 		e.groups = append(e.groups, h.groups...)
 	}
 	e.headerAttrs = slices.Grow(e.headerAttrs, len(h.headerFields))[:len(h.headerFields)]
 	clear(e.headerAttrs)
+	if n := len(h.opts.DefaultAttrs); n > 0 {
+		e.defaultsSeen = slices.Grow(e.defaultsSeen, n)[:n]
+		copy(e.defaultsSeen, h.defaultsSeen)
+		clear(e.defaultsSeen[len(h.defaultsSeen):])
+	}
 	return e
 }
 
@@ -47,12 +96,31 @@ func (e *encoder) free() {
 	if e == nil {
 		return
 	}
+	if e.h.opts.DisablePooling {
+		// drop it, so nothing is reused
+		e.h = nil
+		return
+	}
 	e.h = nil
 	e.buf.Reset()
 	e.attrBuf.Reset()
 	e.multilineAttrBuf.Reset()
 	e.groups = e.groups[:0]
 	e.headerAttrs = e.headerAttrs[:0]
+	e.divider = false
+	e.placeholders = e.placeholders[:0]
+	e.recordAttrs = false
+	e.placeholderAttrs = e.placeholderAttrs[:0]
+	e.orderBuf.Reset()
+	e.orderAttrs = e.orderAttrs[:0]
+	e.errorBuf.Reset()
+	e.errorCaptured = false
+	e.defaultsSeen = e.defaultsSeen[:0]
+	e.yamlPath = e.yamlPath[:0]
+	e.transient = false
+	e.idleMarker = false
+	e.streamTrailer = false
+	e.time = time.Time{}
 	encoderPool.Put(e)
 }
 
@@ -87,7 +155,7 @@ func (e *encoder) encodeTimestamp(tt time.Time) {
 	}
 
 	e.withColor(&e.buf, e.h.opts.Theme.Timestamp, func() {
-		e.buf.AppendTime(tt, e.h.opts.TimeFormat)
+		e.buf.AppendTime(e.normalizeTime(tt), e.h.opts.TimeFormat)
 	})
 }
 
@@ -97,6 +165,10 @@ func (e *encoder) encodeMessage(level slog.Level, msg string) {
 		style = e.h.opts.Theme.MessageDebug
 	}
 
+	if len(e.placeholders) > 0 {
+		msg = e.interpolate(msg)
+	}
+
 	if e.h.opts.ReplaceAttr != nil {
 		attr := e.h.opts.ReplaceAttr(nil, slog.String(slog.MessageKey, msg))
 		attr.Value = attr.Value.Resolve()
@@ -105,14 +177,33 @@ func (e *encoder) encodeMessage(level slog.Level, msg string) {
 			return
 		}
 
+		e.writeGroupPath(attr.Value.Kind() != slog.KindString || attr.Value.String() != "")
 		e.writeColoredValue(&e.buf, attr.Value, style)
 		return
 	}
 
-	e.writeColoredString(&e.buf, strings.TrimSpace(msg), style)
+	msg = strings.TrimSpace(msg)
+	e.writeGroupPath(msg != "")
+	e.writeColoredString(&e.buf, msg, style)
 }
 
-func (e *encoder) encodeHeader(a slog.Attr, width int, rightAlign bool) {
+// writeGroupPath writes the handler's group path and a colon before the message, if
+// GroupOnMessage is set, and a space after, if there's a message.
+func (e *encoder) writeGroupPath(space bool) {
+	if !e.h.opts.GroupOnMessage || e.h.groupPrefix == "" {
+		return
+	}
+	e.withColor(&e.buf, e.h.opts.Theme.Header, func() {
+		e.buf.AppendString(e.h.groupPrefix)
+		e.buf.AppendByte(':')
+	})
+	if space {
+		e.buf.AppendByte(' ')
+	}
+}
+
+func (e *encoder) encodeHeader(a slog.Attr, hf headerField) {
+	width, rightAlign := hf.width, hf.rightAlign
 	if a.Value.Equal(slog.Value{}) {
 		// just pad as needed
 		if width > 0 {
@@ -123,39 +214,23 @@ func (e *encoder) encodeHeader(a slog.Attr, width int, rightAlign bool) {
 
 	e.withColor(&e.buf, e.h.opts.Theme.Header, func() {
 		l := len(e.buf)
+		e.valueFormat = e.valueFormatFor(hf.groupPrefix, hf.key, a.Value.Kind())
 		e.writeValue(&e.buf, a.Value)
+		e.valueFormat = valueFormat{}
 		if width <= 0 {
 			return
 		}
 		// truncate or pad to required width
-		remainingWidth := l + width - len(e.buf)
-		if remainingWidth < 0 {
-			// truncate
-			e.buf = e.buf[:l+width]
-		} else if remainingWidth > 0 {
-			if rightAlign {
-				// For right alignment, shift the text right in-place:
-				// 1. Get the text length
-				textLen := len(e.buf) - l
-				// 2. Add padding to reach final width
-				e.buf.Pad(remainingWidth, ' ')
-				// 3. Move the text to the right by copying from end to start
-				for i := 0; i < textLen; i++ {
-					e.buf[len(e.buf)-1-i] = e.buf[l+textLen-1-i]
-				}
-				// 4. Fill the left side with spaces
-				for i := 0; i < remainingWidth; i++ {
-					e.buf[l+i] = ' '
-				}
-			} else {
-				// Left align - just pad with spaces
-				e.buf.Pad(remainingWidth, ' ')
-			}
+		if displayWidth(e.buf[l:]) > width {
+			e.buf = appendTruncated(e.buf[:l], e.buf[l:], width)
 		}
+		// a wide character may not fit in the last column, leaving
+		// truncated values short of width
+		e.buf.PadFrom(l, width, rightAlign)
 	})
 }
 
-func (e *encoder) encodeLevel(l slog.Level, abbreviated bool) {
+func (e *encoder) encodeLevel(l slog.Level, f levelField) {
 	var val slog.Value
 	var writeVal bool
 
@@ -182,48 +257,51 @@ func (e *encoder) encodeLevel(l slog.Level, abbreviated bool) {
 	}
 
 	var style ANSIMod
-	var str string
+	var abbr, full string
 	var delta int
 	switch {
 	case l >= slog.LevelError:
 		style = e.h.opts.Theme.LevelError
-		str = "ERR"
-		if !abbreviated {
-			str = "ERROR"
-		}
+		abbr, full = "ERR", "ERROR"
 		delta = int(l - slog.LevelError)
 	case l >= slog.LevelWarn:
 		style = e.h.opts.Theme.LevelWarn
-		str = "WRN"
-		if !abbreviated {
-			str = "WARN"
-		}
+		abbr, full = "WRN", "WARN"
 		delta = int(l - slog.LevelWarn)
 	case l >= slog.LevelInfo:
 		style = e.h.opts.Theme.LevelInfo
-		str = "INF"
-		if !abbreviated {
-			str = "INFO"
-		}
+		abbr, full = "INF", "INFO"
 		delta = int(l - slog.LevelInfo)
-	case l >= slog.LevelDebug:
-		style = e.h.opts.Theme.LevelDebug
-		str = "DBG"
-		if !abbreviated {
-			str = "DEBUG"
-		}
-		delta = int(l - slog.LevelDebug)
 	default:
 		style = e.h.opts.Theme.LevelDebug
-		str = "DBG"
-		if !abbreviated {
-			str = "DEBUG"
-		}
+		abbr, full = "DBG", "DEBUG"
 		delta = int(l - slog.LevelDebug)
 	}
-	if writeVal {
+
+	if f.width > 0 {
+		start := len(e.buf)
+		defer func() {
+			e.buf.PadFrom(start, f.width, f.rightAlign)
+		}()
+	}
+
+	switch {
+	case writeVal:
 		e.writeColoredValue(&e.buf, val, style)
-	} else {
+	case f.format == levelNumeric:
+		e.withColor(&e.buf, style, func() {
+			e.buf.AppendInt(int64(l))
+		})
+	case f.format == levelChar:
+		if e.h.opts.InverseLevelBadge && style != "" {
+			style += ToANSICode(Inverse)
+		}
+		e.writeColoredString(&e.buf, abbr[:1], style)
+	default:
+		str := abbr
+		if f.format == levelFull {
+			str = full
+		}
 		if delta != 0 {
 			str = fmt.Sprintf("%s%+d", str, delta)
 		}
@@ -253,10 +331,28 @@ func (e *encoder) encodeSource(src slog.Source) {
 	e.writeColoredValue(&e.buf, v, e.h.opts.Theme.Source)
 }
 
+// encodeContext encodes the attrs added with WithAttrs, which are nested inside their
+// groups, from the root, for ReplaceAttrPerRecord.
+func (e *encoder) encodeContext(attrs []slog.Attr) {
+	// the groups are in the attrs, so start with none, without allocating
+	groups := e.groups
+	e.groups = groups[len(groups):]
+	for _, a := range attrs {
+		e.encodeAttr("", a)
+	}
+	e.groups = groups
+}
+
 func (e *encoder) encodeAttr(groupPrefix string, a slog.Attr) {
 
 	a.Value = a.Value.Resolve()
-	if a.Value.Kind() != slog.KindGroup && e.h.opts.ReplaceAttr != nil {
+	fromRecord := e.recordAttrs
+	if a.Value.Kind() == slog.KindAny {
+		if v, ok := a.Value.Any().(recordValue); ok {
+			a.Value, fromRecord = v.Value, true
+		}
+	}
+	if e.h.opts.ReplaceAttr != nil && (a.Value.Kind() != slog.KindGroup || e.h.opts.ReplaceGroupAttrs) {
 		a = e.h.opts.ReplaceAttr(e.groups, a)
 		a.Value = a.Value.Resolve()
 	}
@@ -265,9 +361,36 @@ func (e *encoder) encodeAttr(groupPrefix string, a slog.Attr) {
 		return
 	}
 
+	if e.h.opts.KeyPattern != nil && a.Key != "" {
+		a.Key = e.checkKey(a.Key)
+	}
+
+	if len(e.defaultsSeen) > 0 {
+		e.markDefault(groupPrefix, a.Key)
+	}
+
+	if e.h.opts.NestedYAML && e.encodeYAMLAttr(groupPrefix, a) {
+		return
+	}
+
 	value := a.Value
 
+	if len(e.h.opts.PrettyJSONKeys) > 0 && e.isPrettyJSON(groupPrefix, a.Key) {
+		if s, ok := prettyJSON(value); ok {
+			// values with newlines are moved to the trailer
+			value = slog.StringValue(s)
+			a.Value = value
+		}
+	}
+
 	if value.Kind() == slog.KindGroup {
+		if a.Key == "" {
+			// inline group
+			for _, attr := range value.Group() {
+				e.encodeAttr(groupPrefix, attr)
+			}
+			return
+		}
 		subgroup := a.Key
 		if groupPrefix != "" {
 			subgroup = groupPrefix + "." + a.Key
@@ -284,31 +407,92 @@ func (e *encoder) encodeAttr(groupPrefix string, a slog.Attr) {
 		return
 	}
 
-	for i, f := range e.h.headerFields {
-		if f.key == a.Key && f.groupPrefix == groupPrefix {
-			e.headerAttrs[i] = a
+	if e.h.opts.ValidateAttr != nil {
+		if err := e.h.opts.ValidateAttr(internal.JoinKey(groupPrefix, a.Key), value); err != nil {
+			// annotate after the attr is written, wherever it ends up
+			defer e.writeViolation(groupPrefix, a.Key, err)
+		}
+	}
+
+	if e.captureHeader(groupPrefix, a) {
+		return
+	}
+
+	if e.h.hasErrorField && e.captureError(groupPrefix, a) {
+		return
+	}
+
+	if len(e.placeholders) > 0 && fromRecord && e.capturePlaceholder(groupPrefix, a) && !e.h.opts.KeepInterpolatedAttrs {
+		return
+	}
+
+	if a.Key == e.h.opts.TransientKey && a.Key != "" {
+		e.transient = value.Kind() == slog.KindBool && value.Bool()
+		return
+	}
+
+	if value.Kind() == slog.KindAny {
+		switch v := value.Any().(type) {
+		case dividerValue:
+			e.divider = true
+			return
+		case RawValue:
+			e.multilineAttrBuf.AppendByte('\n')
+			e.multilineAttrBuf.AppendString(string(v))
 			return
 		}
 	}
 
+	if value.Kind() == slog.KindBool && value.Bool() && e.isFlag(groupPrefix, a.Key) {
+		e.writeFlag(a.Key, groupPrefix)
+		return
+	}
+
 	offset := len(e.attrBuf)
-	valOffset := e.writeAttr(a, groupPrefix)
-
-	// check if the last attr written has newlines in it
-	// if so, move it to the trailerBuf
-	if bytes.IndexByte(e.attrBuf[offset:], '\n') >= 0 {
-		if internal.FeatureFlagNewMultilineAttrs {
-			val := e.attrBuf[valOffset:]
-			e.writeMultilineAttr(a.Key, groupPrefix, val)
-		} else {
-			e.multilineAttrBuf.Append(e.attrBuf[offset:])
+	if e.h.opts.TrailerChunkSize > 0 {
+		if e.encodeTrailerAttr(groupPrefix, a) {
+			return
 		}
+	} else {
+		e.valueFormat = e.valueFormatFor(groupPrefix, a.Key, value.Kind())
+		valOffset := e.writeAttr(&e.attrBuf, a, groupPrefix)
+		e.valueFormat = valueFormat{}
+
+		// check if the last attr written has newlines in it
+		// if so, move it to the trailerBuf
+		if bytes.IndexByte(e.attrBuf[offset:], '\n') >= 0 {
+			if internal.FeatureFlagNewMultilineAttrs {
+				val := e.attrBuf[valOffset:]
+				e.writeMultilineAttr(a.Key, groupPrefix, val)
+			} else {
+				e.multilineAttrBuf.Append(e.attrBuf[offset:])
+			}
 
-		// rewind the middle buffer
-		e.attrBuf = e.attrBuf[:offset]
+			// rewind the middle buffer
+			e.attrBuf = e.attrBuf[:offset]
+			return
+		}
+	}
+
+	if len(e.h.opts.AttrOrder) > 0 {
+		if idx := e.attrOrderIndex(groupPrefix, a.Key); idx >= 0 {
+			e.moveToOrderBuf(idx, offset)
+		}
 	}
 }
 
+// captureHeader stores a in headerAttrs if it matches one of the header fields, and
+// reports whether it did.  Captured attrs are printed in the header, not the attrs.
+func (e *encoder) captureHeader(groupPrefix string, a slog.Attr) bool {
+	for i, f := range e.h.headerFields {
+		if f.key == a.Key && f.groupPrefix == groupPrefix {
+			e.headerAttrs[i] = a
+			return true
+		}
+	}
+	return false
+}
+
 func (e *encoder) withColor(b *buffer, c ANSIMod, f func()) {
 	if c == "" || e.h.opts.NoColor {
 		f()
@@ -325,83 +509,245 @@ func (e *encoder) writeColoredString(w *buffer, s string, c ANSIMod) {
 	})
 }
 
-// writeAttr encodes the attr to the attrBuf.  The group will be prepended
+// writeAttr encodes the attr to buf, usually the attrBuf.  The group will be prepended
 // to the key, joined with a '.'
 //
 // returns the offset where the value starts, which may be used by the
 // caller to split the key and value
-func (e *encoder) writeAttr(a slog.Attr, group string) int {
+func (e *encoder) writeAttr(buf *buffer, a slog.Attr, group string) int {
 	value := a.Value
 
+	e.writeKey(buf, group, a.Key)
+
+	style := e.h.opts.Theme.valueStyle(value.Kind())
+	switch value.Kind() {
+	case slog.KindAny:
+		if _, ok := value.Any().(error); ok {
+			style = e.h.opts.Theme.AttrValueError
+		}
+	case slog.KindDuration:
+		if a.Key == ElapsedKey {
+			style = e.h.opts.Theme.Elapsed
+		}
+	}
+	valOffset := len(*buf)
+	if d, ok := diffValue(a); ok {
+		e.writeDiff(buf, d)
+		return valOffset
+	}
+	if s, syn := e.syntaxValue(a); syn != syntaxNone {
+		e.writeSyntax(buf, s, syn)
+		return valOffset
+	}
+	if value.Kind() == slog.KindAny {
+		if t, ok := value.Any().(TableValue); ok {
+			e.writeTable(buf, t)
+			return valOffset
+		}
+	}
+	e.writeColoredValue(buf, value, style)
+	return valOffset
+}
+
+// durationOptions returns the options for formatting durations.
+func (e *encoder) durationOptions() DurationOptions {
+	return DurationOptions{
+		Units:          e.h.opts.DurationUnits,
+		NegativeParens: e.h.opts.DurationNegativeParens,
+		ZeroDash:       e.h.opts.DurationZeroDash,
+	}
+}
+
+// valueFormat is the formatting chosen for a value by its key.
+type valueFormat struct {
+	// isoDuration is set if the key is in ISO8601DurationKeys
+	isoDuration bool
+	// percent is set if the key is in PercentKeys
+	percent     bool
+	percentPrec int
+}
+
+// valueFormatFor returns the formatting for a value of the kind with the key.
+// Only the options which apply to the kind are checked.
+func (e *encoder) valueFormatFor(groupPrefix, key string, kind slog.Kind) valueFormat {
+	var f valueFormat
+	switch kind {
+	case slog.KindDuration:
+		f.isoDuration = e.isISODurationKey(groupPrefix, key)
+	case slog.KindFloat64:
+		f.percentPrec, f.percent = e.percentPrecision(groupPrefix, key)
+	}
+	return f
+}
+
+// isISODurationKey reports whether the key is one of the ISO8601DurationKeys.
+func (e *encoder) isISODurationKey(groupPrefix, key string) bool {
+	for _, k := range e.h.opts.ISO8601DurationKeys {
+		if matchKey(k, groupPrefix, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlag reports whether the key is one of the FlagKeys.
+func (e *encoder) isFlag(groupPrefix, key string) bool {
+	for _, k := range e.h.opts.FlagKeys {
+		if matchKey(k, groupPrefix, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFlag writes a bare flag for a boolean attr, formatted with FlagFormat.
+func (e *encoder) writeFlag(key, group string) {
+	before, after, _ := strings.Cut(e.h.opts.FlagFormat, "%s")
 	e.attrBuf.AppendByte(' ')
 	e.withColor(&e.attrBuf, e.h.opts.Theme.AttrKey, func() {
+		e.attrBuf.AppendString(before)
 		if group != "" {
 			e.attrBuf.AppendString(group)
 			e.attrBuf.AppendByte('.')
 		}
-		e.attrBuf.AppendString(a.Key)
-		e.attrBuf.AppendByte('=')
+		e.attrBuf.AppendString(key)
+		e.attrBuf.AppendString(after)
 	})
+}
 
-	style := e.h.opts.Theme.AttrValue
-	if value.Kind() == slog.KindAny {
-		if _, ok := value.Any().(error); ok {
-			style = e.h.opts.Theme.AttrValueError
-		}
+// matchKey reports whether pattern equals the full key of an attr, i.e. the
+// key joined to the group prefix with ".", without allocating the full key.
+func matchKey(pattern, groupPrefix, key string) bool {
+	if groupPrefix == "" {
+		return pattern == key
 	}
-	valOffset := len(e.attrBuf)
-	e.writeColoredValue(&e.attrBuf, value, style)
-	return valOffset
+	return len(pattern) == len(groupPrefix)+1+len(key) &&
+		strings.HasPrefix(pattern, groupPrefix) &&
+		pattern[len(groupPrefix)] == '.' &&
+		strings.HasSuffix(pattern, key)
 }
 
 func (e *encoder) writeMultilineAttr(key, group string, value []byte) {
-	e.multilineAttrBuf.AppendByte('\n')
-	e.withColor(&e.multilineAttrBuf, e.h.opts.Theme.AttrKey, func() {
-		e.multilineAttrBuf.AppendString("=== ")
+	e.writeMultilineHeader(&e.multilineAttrBuf, key, group)
+	e.multilineAttrBuf.Append(value)
+}
+
+// writeMultilineHeader writes the "=== key ===" line which precedes a multiline value
+// in the trailer to buf.
+func (e *encoder) writeMultilineHeader(buf *buffer, key, group string) {
+	buf.AppendByte('\n')
+	e.withColor(buf, e.h.opts.Theme.AttrKey, func() {
+		buf.AppendString("=== ")
 		if group != "" {
-			e.multilineAttrBuf.AppendString(group)
-			e.multilineAttrBuf.AppendByte('.')
+			buf.AppendString(group)
+			buf.AppendByte('.')
 		}
-		e.multilineAttrBuf.AppendString(key)
-		e.multilineAttrBuf.AppendString(" ===\n")
+		buf.AppendString(key)
+		buf.AppendString(" ===\n")
 	})
-	e.multilineAttrBuf.Append(value)
+}
+
+// encodeTrailerAttr renders the attr straight into the end of the multilineAttrBuf, for
+// HandlerOptions.TrailerChunkSize, so a multiline value is never held in memory twice.
+// If the value turns out to be on one line, it's moved to the attrBuf, and false is
+// returned, so the caller carries on with it as with any other attr.
+func (e *encoder) encodeTrailerAttr(groupPrefix string, a slog.Attr) bool {
+	start := len(e.multilineAttrBuf)
+	e.valueFormat = e.valueFormatFor(groupPrefix, a.Key, a.Value.Kind())
+	valOffset := e.writeAttr(&e.multilineAttrBuf, a, groupPrefix)
+	e.valueFormat = valueFormat{}
+
+	if bytes.IndexByte(e.multilineAttrBuf[start:], '\n') < 0 {
+		e.attrBuf.Append(e.multilineAttrBuf[start:])
+		e.multilineAttrBuf = e.multilineAttrBuf[:start]
+		return false
+	}
+	if internal.FeatureFlagNewMultilineAttrs {
+		// replace the key with the header, in place, using the end of the attrBuf
+		// as scratch space
+		offset := len(e.attrBuf)
+		e.writeMultilineHeader(&e.attrBuf, a.Key, groupPrefix)
+		e.multilineAttrBuf = slices.Replace(e.multilineAttrBuf, start, valOffset, e.attrBuf[offset:]...)
+		e.attrBuf = e.attrBuf[:offset]
+	}
+	return true
 }
 
 func (e *encoder) writeValue(buf *buffer, value slog.Value) {
 	switch value.Kind() {
 	case slog.KindInt64:
+		if e.h.opts.Locale != nil {
+			e.writeLocaleNumber(buf, value)
+			return
+		}
 		buf.AppendInt(value.Int64())
 	case slog.KindBool:
 		buf.AppendBool(value.Bool())
 	case slog.KindFloat64:
-		buf.AppendFloat(value.Float64())
+		if f := value.Float64(); e.valueFormat.percent && f >= 0 && f <= 1 {
+			buf.AppendFloatFormat(f*100, 'f', e.valueFormat.percentPrec)
+			buf.AppendByte('%')
+			return
+		}
+		if e.h.opts.Locale != nil {
+			e.writeLocaleNumber(buf, value)
+			return
+		}
+		e.appendFloat(buf, value.Float64())
 	case slog.KindTime:
-		buf.AppendTime(value.Time(), e.h.opts.TimeFormat)
+		buf.AppendTime(e.normalizeTime(value.Time()), e.h.opts.TimeFormat)
 	case slog.KindUint64:
+		if e.h.opts.Locale != nil {
+			e.writeLocaleNumber(buf, value)
+			return
+		}
 		buf.AppendUint(value.Uint64())
 	case slog.KindDuration:
-		buf.AppendDuration(value.Duration())
+		if e.h.opts.DurationFormat == DurationISO8601 || e.valueFormat.isoDuration {
+			buf.AppendISODuration(e.normalizeDuration(value.Duration()), e.durationOptions())
+		} else {
+			buf.AppendDuration(e.normalizeDuration(value.Duration()), e.durationOptions())
+		}
 	case slog.KindAny:
 		switch v := value.Any().(type) {
 		case error:
-			if _, ok := v.(fmt.Formatter); ok {
+			if e.h.opts.CausedBy && hasErrorStack(v) {
+				e.writeCausedBy(buf, v)
+			} else if _, ok := v.(fmt.Formatter); ok {
 				fmt.Fprintf(buf, "%+v", v)
 			} else {
 				buf.AppendString(v.Error())
 			}
 			return
+		case netip.Addr, netip.Prefix, netip.AddrPort, net.IP, *net.IPNet, *url.URL:
+			e.writeNetValue(buf, v)
+			return
 		case fmt.Stringer:
 			buf.AppendString(v.String())
 			return
 		case *slog.Source:
-			buf.AppendString(trimmedPath(v.File, cwd, e.h.opts.TruncateSourcePath))
-			buf.AppendByte(':')
-			buf.AppendInt(int64(v.Line))
+			writeSource := func() {
+				buf.AppendString(e.normalizePath(trimmedPath(v.File, cwd, e.h.opts.TruncateSourcePath)))
+				buf.AppendByte(':')
+				buf.AppendInt(int64(v.Line))
+			}
+			if url := e.sourceURL(v); url != "" && !e.h.opts.NoColor {
+				writeHyperlink(buf, url, writeSource)
+			} else {
+				writeSource()
+			}
 			return
+		default:
+			if buf.AppendNumber(v) {
+				return
+			}
 		}
 		fallthrough
 	case slog.KindString:
+		if e.h.opts.ScrubURLCredentials || e.h.opts.MaskTokens {
+			e.appendScrubbed(buf, value.String())
+			return
+		}
 		fallthrough
 	default:
 		buf.AppendString(value.String())
@@ -450,3 +796,22 @@ func trimmedPath(path string, cwd string, truncate int) string {
 	}
 	return path[start:]
 }
+
+// tail returns the buffer which ends the record: multilineAttrBuf if the trailer is
+// streamed, else buf.
+func (e *encoder) tail() *buffer {
+	if e.streamTrailer {
+		return &e.multilineAttrBuf
+	}
+	return &e.buf
+}
+
+// writeTo writes the rendered record to w, followed by the trailer, if it's streamed.
+func (e *encoder) writeTo(w io.Writer) (int64, error) {
+	n, err := e.buf.WriteTo(w)
+	if err != nil || !e.streamTrailer {
+		return n, err
+	}
+	m, err := e.multilineAttrBuf.WriteChunksTo(w, e.h.opts.TrailerChunkSize)
+	return n + m, err
+}
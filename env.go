@@ -0,0 +1,33 @@
+package console
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// envAttrs returns attrs for the environment variables with the prefix, sorted by name,
+// and nested in group, if it's not empty.
+func envAttrs(prefix, group string) []slog.Attr {
+	if prefix == "" {
+		return nil
+	}
+	var attrs []slog.Attr
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, prefix) {
+			attrs = append(attrs, slog.String(k, v))
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	if group != "" {
+		return []slog.Attr{{Key: group, Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
@@ -0,0 +1,39 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_EnvPrefix(t *testing.T) {
+	t.Setenv("CSTEST_B", "2")
+	t.Setenv("CSTEST_A", "1")
+	t.Setenv("OTHER_CSTEST", "x")
+
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%m %a", EnvPrefix: "CSTEST_"},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.String("foo", "bar")},
+		want:  "msg CSTEST_A=1 CSTEST_B=2 foo=bar\n",
+	}.run(t)
+
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%m %a", EnvPrefix: "CSTEST_", EnvGroup: "env"},
+		msg:  "msg",
+		want: "msg env.CSTEST_A=1 env.CSTEST_B=2\n",
+	}.run(t)
+
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%m %a", EnvPrefix: "NOMATCH_CSTEST_"},
+		msg:  "msg",
+		want: "msg\n",
+	}.run(t)
+}
+
+func TestHandler_EnvPrefixContextAttrs(t *testing.T) {
+	t.Setenv("CSTEST_A", "1")
+	h := NewHandler(nil, &HandlerOptions{EnvPrefix: "CSTEST_"})
+	attrs := h.ContextAttrs()
+	AssertEqual(t, 1, len(attrs))
+	AssertEqual(t, true, attrs[0].Equal(slog.String("CSTEST_A", "1")))
+}
@@ -0,0 +1,41 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+)
+
+// captureError renders the attr into errorBuf, if it matches one of the ErrorKeys, and
+// reports whether it did.  Like headers, the last match wins, so the record's error
+// replaces the context's.  Attrs with multiline values aren't captured.
+func (e *encoder) captureError(groupPrefix string, a slog.Attr) bool {
+	for _, k := range e.h.opts.ErrorKeys {
+		if !matchKey(k, groupPrefix, a.Key) {
+			continue
+		}
+		// render after the current error, so it's kept if this one is multiline
+		offset := len(e.errorBuf)
+		e.writeValue(&e.errorBuf, a.Value)
+		if bytes.IndexByte(e.errorBuf[offset:], '\n') >= 0 {
+			e.errorBuf = e.errorBuf[:offset]
+			return false
+		}
+		e.errorBuf.consume(offset)
+		e.errorCaptured = true
+		return true
+	}
+	return false
+}
+
+// encodeError writes the error captured from the record, or else the error
+// captured from the handler's context.
+func (e *encoder) encodeError() {
+	switch {
+	case e.errorCaptured:
+		e.withColor(&e.buf, e.h.opts.Theme.AttrValueError, func() {
+			e.buf.Append(e.errorBuf)
+		})
+	case e.h.errorMemo != "":
+		e.writeColoredString(&e.buf, e.h.errorMemo, e.h.opts.Theme.AttrValueError)
+	}
+}
@@ -0,0 +1,142 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_ErrorField(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "error",
+			attrs: []slog.Attr{slog.String("foo", "bar"), slog.Any("err", errors.New("boom"))},
+			want:  "INF req: boom foo=bar\n",
+		},
+		{
+			name:  "error key",
+			attrs: []slog.Attr{slog.String("error", "boom")},
+			want:  "INF req: boom\n",
+		},
+		{
+			name:  "no error",
+			attrs: []slog.Attr{slog.String("foo", "bar")},
+			want:  "INF req foo=bar\n",
+		},
+		{
+			name:  "last error wins",
+			attrs: []slog.Attr{slog.String("err", "boom"), slog.String("error", "bang")},
+			want:  "INF req: bang\n",
+		},
+		{
+			name:  "multiline errors don't replace the error",
+			attrs: []slog.Attr{slog.String("err", "boom"), slog.String("error", "line 1\nline 2")},
+			want:  "INF req: boom\n=== error ===\nline 1\nline 2\n",
+		},
+		{
+			name:  "multiline errors stay in attrs",
+			attrs: []slog.Attr{slog.String("err", "line 1\nline 2")},
+			want:  "INF req\n=== err ===\nline 1\nline 2\n",
+		},
+		{
+			name: "context error",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("err", "ctx"), slog.String("a", "1")})
+			},
+			attrs: []slog.Attr{slog.String("foo", "bar")},
+			want:  "INF req: ctx a=1 foo=bar\n",
+		},
+		{
+			name: "record error overrides context",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("err", "ctx")})
+			},
+			attrs: []slog.Attr{slog.String("err", "boom")},
+			want:  "INF req: boom\n",
+		},
+		{
+			name: "later context error overrides earlier",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("err", "ctx")}).WithAttrs([]slog.Attr{slog.String("err", "ctx2")})
+			},
+			attrs: []slog.Attr{slog.String("foo", "bar")},
+			want:  "INF req: ctx2 foo=bar\n",
+		},
+		{
+			name: "grouped keys",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("db")
+			},
+			attrs: []slog.Attr{slog.String("err", "boom")},
+			want:  "INF req db.err=boom\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "req"
+		tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%l %m%{: %e%} %a"}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_ErrorFieldKeys(t *testing.T) {
+	handlerTest{
+		msg:  "req",
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %e %m %a", ErrorKeys: []string{"db.cause"}},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithGroup("db")
+		},
+		attrs: []slog.Attr{slog.String("err", "bang"), slog.String("cause", "boom")},
+		want:  "INF boom req db.err=bang\n",
+	}.run(t)
+}
+
+func TestHandler_ErrorFieldColor(t *testing.T) {
+	handlerTest{
+		msg:   "req",
+		opts:  HandlerOptions{HeaderFormat: "%m %e", Theme: NewDefaultTheme()},
+		attrs: []slog.Attr{slog.Any("err", errors.New("boom"))},
+		want:  styled("req", NewDefaultTheme().Message) + " " + styled("boom", NewDefaultTheme().AttrValueError) + "\n",
+	}.run(t)
+}
+
+func TestHandler_ErrorFieldWithoutVerb(t *testing.T) {
+	handlerTest{
+		msg:   "req",
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"},
+		attrs: []slog.Attr{slog.String("err", "boom")},
+		want:  "INF req err=boom\n",
+	}.run(t)
+}
+
+func TestHandler_ErrorFieldRecordWins(t *testing.T) {
+	tests := []handlerTest{
+		{name: "sorted", opts: HandlerOptions{SortAttrs: true}},
+		{name: "replace attr per record", opts: HandlerOptions{ReplaceAttrPerRecord: true}},
+		{name: "replace attr per record, context after", opts: HandlerOptions{ReplaceAttrPerRecord: true, ContextAfterRecord: true}},
+		{name: "context after", opts: HandlerOptions{ContextAfterRecord: true}},
+	}
+	for _, tt := range tests {
+		tt.msg = "hello"
+		tt.opts.NoColor = true
+		tt.opts.HeaderFormat = "%m%{: %e%} %a"
+		tt.handlerFunc = func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("err", "ctx err")})
+		}
+		tt.attrs = []slog.Attr{slog.String("err", "rec err")}
+		tt.want = "hello: rec err\n"
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_ErrorFieldReencoded(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m%{: %e%} %a"})
+	l := slog.New(h).With("err", "ctx").With("err", "ctx2")
+	l.Info("before")
+	// re-encodes the context attrs
+	h.SetTheme(NewBrightTheme())
+	l.Info("after")
+	AssertEqual(t, "before: ctx2\nafter: ctx2\n", buf.String())
+}
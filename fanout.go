@@ -0,0 +1,96 @@
+package console
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// Destination is one of the writers of a fan-out writer.
+type Destination struct {
+	Writer io.Writer
+	// NoColor strips ANSI escape sequences from the output before it's written to Writer.
+	NoColor bool
+	// OnError, if set, is called with the error whenever a write to Writer fails, including
+	// short writes, which fail with io.ErrShortWrite.  It's called after the write has been
+	// made to every destination, so it may log.
+	OnError func(err error)
+}
+
+type fanoutWriter struct {
+	mu    sync.Mutex
+	dsts  []Destination
+	plain []byte
+}
+
+// NewFanoutWriter returns a writer which writes to each of the destinations, in order.
+// Output is stripped of ANSI escape sequences for destinations with NoColor set, so
+// a single Handler with color enabled can write colored output to a terminal, and plain
+// text to a file, without encoding each record twice:
+//
+//	w := console.NewFanoutWriter(
+//		console.Destination{Writer: os.Stderr},
+//		console.Destination{Writer: f, NoColor: true},
+//	)
+//	h := console.NewHandler(w, nil)
+//
+// Every destination is written to, even if writing to an earlier one fails.  Failures are
+// reported to the failing destination's OnError.  If any destination took the write, it
+// succeeds, so a destination which is permanently broken doesn't make the Handler treat
+// the whole output as failed, and silence the destinations which work.  Only if every
+// destination failed are the errors joined and returned.
+func NewFanoutWriter(dsts ...Destination) io.Writer {
+	return &fanoutWriter{dsts: dsts}
+}
+
+func (f *fanoutWriter) Write(p []byte) (int, error) {
+	type failure struct {
+		onError func(error)
+		err     error
+	}
+	var failures []failure
+	written := f.write(p, func(d Destination, err error) {
+		failures = append(failures, failure{d.OnError, err})
+	})
+	errs := make([]error, 0, len(failures))
+	for _, fl := range failures {
+		if fl.onError != nil {
+			fl.onError(fl.err)
+		}
+		errs = append(errs, fl.err)
+	}
+	if !written && len(errs) > 0 {
+		return 0, errors.Join(errs...)
+	}
+	return len(p), nil
+}
+
+// write writes p to each destination, calling fail for each which fails, and reports
+// whether any took it.
+func (f *fanoutWriter) write(p []byte, fail func(Destination, error)) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// strip the output once, and only if some destination needs it
+	var stripped, written bool
+	for _, d := range f.dsts {
+		b := p
+		if d.NoColor {
+			if !stripped {
+				f.plain = appendStripped(f.plain[:0], p)
+				stripped = true
+			}
+			b = f.plain
+		}
+		n, err := d.Writer.Write(b)
+		if err == nil && n < len(b) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			fail(d, err)
+		} else {
+			written = true
+		}
+	}
+	return written
+}
@@ -0,0 +1,95 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestFanoutWriter(t *testing.T) {
+	var colored, plain bytes.Buffer
+	w := NewFanoutWriter(
+		Destination{Writer: &colored},
+		Destination{Writer: &plain, NoColor: true},
+	)
+	theme := NewDefaultTheme()
+	l := slog.New(NewHandler(w, &HandlerOptions{Theme: theme, HeaderFormat: "%m %a"}))
+	l.Info("hello", "foo", "bar")
+
+	AssertEqual(t, styled("hello", theme.Message)+" "+styled("foo=", theme.AttrKey)+styled("bar", theme.AttrValue)+"\n", colored.String())
+	AssertEqual(t, "hello foo=bar\n", plain.String())
+}
+
+type shortWriter struct{ bytes.Buffer }
+
+func (w *shortWriter) Write(p []byte) (int, error) { return w.Buffer.Write(p[:len(p)/2]) }
+
+func TestFanoutWriter_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	var errs []error
+	errBoom := errors.New("boom")
+	w := NewFanoutWriter(
+		Destination{Writer: errWriter{errBoom}, OnError: func(err error) { errs = append(errs, err) }},
+		Destination{Writer: &buf, NoColor: true},
+	)
+	n, err := w.Write([]byte("\x1b[31mhi\x1b[0m\n"))
+	// written to one of the destinations, the failure is only reported to the other
+	AssertNoError(t, err)
+	AssertEqual(t, 12, n)
+	AssertEqual(t, "hi\n", buf.String())
+	AssertEqual(t, 1, len(errs))
+	AssertEqual(t, errBoom, errs[0])
+
+	w = NewFanoutWriter(Destination{Writer: errWriter{errBoom}})
+	n, err = w.Write([]byte("hi\n"))
+	AssertEqual(t, 0, n)
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+}
+
+func TestFanoutWriter_ShortWrite(t *testing.T) {
+	var short shortWriter
+	var buf bytes.Buffer
+	var errs []error
+	w := NewFanoutWriter(
+		Destination{Writer: &short, OnError: func(err error) { errs = append(errs, err) }},
+		Destination{Writer: &buf},
+	)
+	n, err := w.Write([]byte("hi\n"))
+	AssertNoError(t, err)
+	AssertEqual(t, 3, n)
+	AssertEqual(t, 1, len(errs))
+	AssertEqual(t, io.ErrShortWrite, errs[0])
+
+	w = NewFanoutWriter(Destination{Writer: &short})
+	_, err = w.Write([]byte("hi\n"))
+	if !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("expected short write, got %v", err)
+	}
+}
+
+func TestFanoutWriter_Fallback(t *testing.T) {
+	var buf, fallback bytes.Buffer
+	failures := 0
+	w := NewFanoutWriter(
+		Destination{Writer: errWriter{errors.New("boom")}, OnError: func(error) { failures++ }},
+		Destination{Writer: &buf},
+	)
+	l := slog.New(NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m", Fallback: &fallback}))
+	// a destination failing persistently doesn't fail the output, so the healthy one gets
+	// every record, and nothing goes to the fallback
+	for i := 0; i < 2*writeFailureThreshold+1; i++ {
+		l.Info("hello")
+	}
+	AssertEqual(t, strings.Repeat("hello\n", 2*writeFailureThreshold+1), buf.String())
+	AssertEqual(t, "", fallback.String())
+	AssertEqual(t, 2*writeFailureThreshold+1, failures)
+}
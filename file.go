@@ -0,0 +1,215 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileHandlerOptions are options for a FileHandler.
+type FileHandlerOptions struct {
+	HandlerOptions
+
+	// Perm is the permission used to create the file.  Defaults to 0644.
+	Perm os.FileMode
+
+	// MaxSize is the size in bytes the file may grow to before it is rotated.  When a
+	// write would grow the file beyond MaxSize, the file is renamed to path.1 (path.1
+	// to path.2, and so on), and a new file is opened at path.  Zero disables rotation.
+	MaxSize int64
+
+	// MaxBackups is the number of rotated files to keep.  Older files are removed.  If zero,
+	// the file is removed instead of rotated.
+	MaxBackups int
+}
+
+// FileHandler is a Handler which writes to a file.
+type FileHandler struct {
+	*Handler
+	w *FileWriter
+}
+
+// NewFileHandler opens the file at path for appending, creating it if necessary, and
+// returns a Handler writing to it.  Color is always disabled.
+//
+// Call Reopen to reopen the file after it has been moved by an external tool like logrotate,
+// typically on SIGHUP.  Handlers derived from the FileHandler with WithAttrs and WithGroup
+// write to the reopened file as well.
+func NewFileHandler(path string, opts *FileHandlerOptions) (*FileHandler, error) {
+	if opts == nil {
+		opts = new(FileHandlerOptions)
+	}
+	w, err := OpenFileWriter(path, opts.Perm, opts.MaxSize, opts.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	hopts := opts.HandlerOptions
+	hopts.NoColor = true
+	return &FileHandler{Handler: NewHandler(w, &hopts), w: w}, nil
+}
+
+// Reopen closes and reopens the file.
+func (h *FileHandler) Reopen() error {
+	return h.w.Reopen()
+}
+
+// Close closes the Handler, then the file.
+func (h *FileHandler) Close() error {
+	err := h.Handler.Close()
+	if cerr := h.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// FileWriter appends to a file, and can reopen and rotate it.  It's safe for
+// concurrent use.
+type FileWriter struct {
+	path       string
+	perm       os.FileMode
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	// closed is set by Close.  f is also nil after a failed rotation or reopen, when
+	// the file is opened again by the next write.
+	closed bool
+}
+
+// OpenFileWriter opens the file at path for appending, creating it with perm if necessary.
+// A perm of zero defaults to 0644.  If maxSize is greater than zero, the file is rotated
+// before it grows beyond maxSize bytes, keeping maxBackups rotated files.
+func OpenFileWriter(path string, perm os.FileMode, maxSize int64, maxBackups int) (*FileWriter, error) {
+	if perm == 0 {
+		perm = 0o644
+	}
+	w := &FileWriter{path: path, perm: perm, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.perm)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.f, w.size = f, fi.Size()
+	return nil
+}
+
+// Write implements io.Writer.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.reopenIfNeeded(); err != nil {
+		return 0, err
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// reopenIfNeeded opens the file again if a rotation or reopen failed to, so a
+// transient failure, like a full disk, doesn't end the writes for good.
+func (w *FileWriter) reopenIfNeeded() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.f == nil {
+		return w.open()
+	}
+	return nil
+}
+
+// Reopen closes and reopens the file.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f != nil {
+		if err := w.f.Close(); err != nil {
+			return err
+		}
+		w.f = nil
+	}
+	return w.open()
+}
+
+// Rotate rotates the file now, regardless of its size.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+func (w *FileWriter) rotate() error {
+	if w.f != nil {
+		if err := w.f.Close(); err != nil {
+			return err
+		}
+		w.f = nil
+	}
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+	if err := os.Remove(w.backupPath(w.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backupPath(i), w.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, w.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.open()
+}
+
+func (w *FileWriter) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+// Sync commits the file's contents to stable storage.
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.reopenIfNeeded(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// Close closes the file.  Writes after Close fail with os.ErrClosed.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
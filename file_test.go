@@ -0,0 +1,122 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	AssertNoError(t, err)
+	return string(b)
+}
+
+func TestFileHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	h, err := NewFileHandler(path, &FileHandlerOptions{HandlerOptions: HandlerOptions{HeaderFormat: "%l %m %a"}})
+	AssertNoError(t, err)
+
+	l := slog.New(h)
+	l.Info("hello", "foo", "bar")
+	AssertEqual(t, "INF hello foo=bar\n", readFile(t, path))
+
+	// simulate logrotate moving the file away
+	AssertNoError(t, os.Rename(path, path+".old"))
+	l.With("a", 1).Info("before reopen")
+	AssertNoError(t, h.Reopen())
+	l.With("a", 1).Info("after reopen")
+
+	AssertEqual(t, "INF hello foo=bar\nINF before reopen a=1\n", readFile(t, path+".old"))
+	AssertEqual(t, "INF after reopen a=1\n", readFile(t, path))
+
+	AssertNoError(t, h.Close())
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "closed", 0)); err == nil {
+		t.Error("expected error writing to closed handler")
+	}
+}
+
+func TestFileHandler_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	AssertNoError(t, os.WriteFile(path, []byte("existing\n"), 0o600))
+
+	h, err := NewFileHandler(path, &FileHandlerOptions{HandlerOptions: HandlerOptions{HeaderFormat: "%m"}})
+	AssertNoError(t, err)
+	slog.New(h).Info("hello")
+	AssertNoError(t, h.Close())
+	AssertEqual(t, "existing\nhello\n", readFile(t, path))
+}
+
+func TestFileHandler_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	h, err := NewFileHandler(path, &FileHandlerOptions{
+		HandlerOptions: HandlerOptions{HeaderFormat: "%m"},
+		MaxSize:        8,
+		MaxBackups:     2,
+	})
+	AssertNoError(t, err)
+
+	l := slog.New(h)
+	for _, msg := range []string{"one", "two", "three", "four", "five"} {
+		l.Info(msg)
+	}
+	AssertNoError(t, h.Close())
+
+	AssertEqual(t, "five\n", readFile(t, path))
+	AssertEqual(t, "four\n", readFile(t, path+".1"))
+	AssertEqual(t, "three\n", readFile(t, path+".2"))
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected no third backup, got %v", err)
+	}
+}
+
+func TestFileWriter_RotateWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := OpenFileWriter(path, 0, 0, 0)
+	AssertNoError(t, err)
+	_, err = w.Write([]byte("one\n"))
+	AssertNoError(t, err)
+	AssertNoError(t, w.Rotate())
+	_, err = w.Write([]byte("two\n"))
+	AssertNoError(t, err)
+	AssertNoError(t, w.Close())
+
+	AssertEqual(t, "two\n", readFile(t, path))
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup, got %v", err)
+	}
+}
+
+func TestFileWriter_RotateFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := OpenFileWriter(path, 0, 8, 1)
+	AssertNoError(t, err)
+	_, err = w.Write([]byte("one\n"))
+	AssertNoError(t, err)
+
+	// a backup which can't be removed fails the rotation
+	AssertNoError(t, os.MkdirAll(filepath.Join(path+".1", "x"), 0o755))
+	_, err = w.Write([]byte("twotwo\n"))
+	AssertError(t, err)
+	_, err = w.Write([]byte("twotwo\n"))
+	AssertError(t, err)
+	if errors.Is(err, os.ErrClosed) {
+		t.Error("a failed rotation closed the writer for good")
+	}
+
+	// once the cause is fixed, writes resume
+	AssertNoError(t, os.RemoveAll(path+".1"))
+	_, err = w.Write([]byte("three\n"))
+	AssertNoError(t, err)
+	AssertNoError(t, w.Close())
+	AssertEqual(t, "one\n", readFile(t, path+".1"))
+	AssertEqual(t, "three\n", readFile(t, path))
+
+	_, err = w.Write([]byte("closed\n"))
+	AssertEqual(t, os.ErrClosed, err)
+}
@@ -0,0 +1,67 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_FlagKeys(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "true",
+			attrs: []slog.Attr{slog.Bool("dryrun", true), slog.String("foo", "bar")},
+			want:  "INF run +dryrun foo=bar\n",
+		},
+		{
+			name:  "false",
+			attrs: []slog.Attr{slog.Bool("dryrun", false)},
+			want:  "INF run dryrun=false\n",
+		},
+		{
+			name:  "not a flag key",
+			attrs: []slog.Attr{slog.Bool("verbose", true)},
+			want:  "INF run verbose=true\n",
+		},
+		{
+			name:  "not a bool",
+			attrs: []slog.Attr{slog.String("dryrun", "true")},
+			want:  "INF run dryrun=true\n",
+		},
+		{
+			name:  "group",
+			attrs: []slog.Attr{slog.Group("opts", slog.Bool("force", true), slog.Bool("dryrun", true))},
+			want:  "INF run +opts.force opts.dryrun=true\n",
+		},
+		{
+			name:  "format",
+			opts:  HandlerOptions{FlagFormat: "[%s]"},
+			attrs: []slog.Attr{slog.Bool("dryrun", true)},
+			want:  "INF run [dryrun]\n",
+		},
+		{
+			name: "with attrs",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Bool("dryrun", true)})
+			},
+			want: "INF run +dryrun\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "run"
+		tt.opts.NoColor = true
+		tt.opts.HeaderFormat = "%l %m %a"
+		tt.opts.FlagKeys = []string{"dryrun", "opts.force"}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	AssertEqual(t, true, matchKey("a", "", "a"))
+	AssertEqual(t, false, matchKey("a", "", "b"))
+	AssertEqual(t, true, matchKey("g.a", "g", "a"))
+	AssertEqual(t, true, matchKey("g.h.a", "g.h", "a"))
+	AssertEqual(t, false, matchKey("a", "g", "a"))
+	AssertEqual(t, false, matchKey("gxa", "g", "a"))
+	AssertEqual(t, false, matchKey("g.ab", "g", "a"))
+}
@@ -0,0 +1,28 @@
+package console
+
+import "math"
+
+// appendFloat appends f, in fixed point if its decimal exponent is within
+// FloatFixedExp, or else in the shortest form, which uses an exponent for very
+// large and small values, like strconv.FormatFloat(f, 'g', -1, 64).
+func (e *encoder) appendFloat(buf *buffer, f float64) {
+	if n := e.h.opts.FloatFixedExp; n > 0 {
+		abs := math.Abs(f)
+		if abs == 0 || (abs >= math.Pow10(-n) && abs < math.Pow10(n+1)) {
+			buf.AppendFloatFormat(f, 'f', -1)
+			return
+		}
+	}
+	buf.AppendFloat(f)
+}
+
+// percentPrecision returns the precision of the key in PercentKeys, and whether
+// it's one of them.
+func (e *encoder) percentPrecision(groupPrefix, key string) (int, bool) {
+	for k, prec := range e.h.opts.PercentKeys {
+		if matchKey(k, groupPrefix, key) {
+			return prec, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package console
+
+import (
+	"log/slog"
+	"math"
+	"testing"
+)
+
+func TestHandler_FloatFixedExp(t *testing.T) {
+	tests := []struct {
+		f          float64
+		def, fixed string
+	}{
+		{0, "0", "0"},
+		{1.5, "1.5", "1.5"},
+		{0.0001, "0.0001", "0.0001"},
+		{0.000001, "1e-06", "0.000001"},
+		{-0.0000015, "-1.5e-06", "-0.0000015"},
+		{0.00000099, "9.9e-07", "9.9e-07"},
+		{1e6, "1e+06", "1000000"},
+		{9999999, "9.999999e+06", "9999999"},
+		{1e7, "1e+07", "1e+07"},
+		{-1.25e7, "-1.25e+07", "-1.25e+07"},
+		{math.Inf(1), "+Inf", "+Inf"},
+		{math.NaN(), "NaN", "NaN"},
+	}
+	for _, tt := range tests {
+		handlerTest{
+			opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a"},
+			attrs: []slog.Attr{slog.Float64("f", tt.f)},
+			want:  "f=" + tt.def + "\n",
+		}.run(t)
+		handlerTest{
+			opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", FloatFixedExp: 6},
+			attrs: []slog.Attr{slog.Float64("f", tt.f)},
+			want:  "f=" + tt.fixed + "\n",
+		}.run(t)
+	}
+}
+
+func TestHandler_PercentKeys(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%a", PercentKeys: map[string]int{"hit_rate": 1, "cache.ratio": 0}},
+		attrs: []slog.Attr{
+			slog.Float64("hit_rate", 0.9344),
+			slog.Group("cache", slog.Float64("ratio", 0.5), slog.Float64("hit_rate", 0.5)),
+			slog.Float64("other", 0.5),
+		},
+		want: "hit_rate=93.4% cache.ratio=50% cache.hit_rate=0.5 other=0.5\n",
+	}.run(t)
+
+	// out of range values are printed as floats
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", PercentKeys: map[string]int{"r": 2}},
+		attrs: []slog.Attr{slog.Float64("r", 0), slog.Float64("r", 1), slog.Float64("r", 1.5), slog.Float64("r", -0.1)},
+		want:  "r=0.00% r=100.00% r=1.5 r=-0.1\n",
+	}.run(t)
+
+	// in headers too
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%[load]h %m", PercentKeys: map[string]int{"load": 0}},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Float64("load", 0.25)},
+		want:  "25% msg\n",
+	}.run(t)
+}
@@ -0,0 +1,84 @@
+package console
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// thinRule is the character of the rule printed by GapSeparator and MultilineSeparator.
+const thinRule = "┈"
+
+// Separator is a line printed between records, see HandlerOptions.MultilineSeparator.
+type Separator int
+
+const (
+	// SeparatorNone prints nothing.
+	SeparatorNone Separator = iota
+	// SeparatorBlank prints a blank line.
+	SeparatorBlank
+	// SeparatorRule prints a thin, dim rule, as wide as the output, see Handler.SetWidth.
+	SeparatorRule
+)
+
+// writeGap writes a separator to the output if the record at t follows the previous record
+// by at least HandlerOptions.GapSeparator.  It must be called with the shared mutex held.
+func (h *Handler) writeGap(t time.Time) error {
+	last := h.shared.lastRecord
+	h.shared.lastRecord = t
+	if last.IsZero() || t.Sub(last) < h.opts.GapSeparator {
+		return nil
+	}
+	if h.opts.GapSeparatorBlank {
+		return h.writeSeparator(SeparatorBlank)
+	}
+	return h.writeSeparator(SeparatorRule)
+}
+
+// isMultiline reports whether line, which ends with a newline, is more than one line.
+func isMultiline(line []byte) bool {
+	return bytes.IndexByte(line[:len(line)-1], '\n') >= 0
+}
+
+// writeMultilineSeparator writes HandlerOptions.MultilineSeparator before and after
+// multiline records, but only once between two of them.  It must be called with the
+// shared mutex held, before the record is written if before is true, else after.
+func (h *Handler) writeMultilineSeparator(multiline, before bool) error {
+	if before {
+		if !multiline || h.shared.separated || !h.shared.wrote {
+			return nil
+		}
+	} else {
+		h.shared.wrote = true
+		h.shared.separated = multiline
+		if !multiline {
+			return nil
+		}
+	}
+	return h.writeSeparator(h.opts.MultilineSeparator)
+}
+
+// writeSeparator writes a separator line to the output.  It must be called with the shared
+// mutex held.
+func (h *Handler) writeSeparator(s Separator) error {
+	var b buffer
+	switch s {
+	case SeparatorNone:
+		return nil
+	case SeparatorBlank:
+		_, err := io.WriteString(h.out, "\n")
+		return err
+	}
+	rule := strings.Repeat(thinRule, h.width())
+	if h.opts.NoColor {
+		b.AppendString(rule)
+	} else {
+		b.AppendString(string(ToANSICode(Faint)))
+		b.AppendString(rule)
+		b.AppendString(string(ResetMod))
+	}
+	b.AppendByte('\n')
+	_, err := b.WriteTo(h.out)
+	return err
+}
@@ -0,0 +1,55 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_GapSeparator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := func(h *Handler, offsets ...time.Duration) {
+		for _, d := range offsets {
+			rec := slog.NewRecord(start.Add(d), slog.LevelInfo, "m", 0)
+			AssertNoError(t, h.Handle(context.Background(), rec))
+		}
+	}
+	rule := strings.Repeat(thinRule, 10)
+
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m", Width: 10, GapSeparator: time.Second})
+	log(h, 0, 500*time.Millisecond, 2*time.Second, 2100*time.Millisecond)
+	AssertEqual(t, "m\nm\n"+rule+"\nm\nm\n", buf.String())
+
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m", GapSeparator: time.Second, GapSeparatorBlank: true})
+	log(h.WithAttrs(nil).(*Handler), 0, 2*time.Second)
+	log(h, 4*time.Second)
+	AssertEqual(t, "m\n\nm\n\nm\n", buf.String())
+
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{HeaderFormat: "%m", Width: 10, GapSeparator: time.Second})
+	log(h, 0, 2*time.Second)
+	AssertEqual(t, styled("m", NewDefaultTheme().Message)+"\n"+styled(rule, ToANSICode(Faint))+"\n"+styled("m", NewDefaultTheme().Message)+"\n", buf.String())
+}
+
+func TestHandler_MultilineSeparator(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", MultilineSeparator: SeparatorBlank})
+	l := slog.New(h)
+	l.Info("a")
+	l.Info("b", "stack", "1\n2")
+	l.Info("c", "stack", "3\n4")
+	l.Info("d")
+	AssertEqual(t, "a\n\nb\n=== stack ===\n1\n2\n\nc\n=== stack ===\n3\n4\n\nd\n", buf.String())
+
+	// no leading separator on the first line
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", Width: 4, MultilineSeparator: SeparatorRule})
+	slog.New(h).Info("b", "stack", "1\n2")
+	slog.New(h).Info("c")
+	AssertEqual(t, "b\n=== stack ===\n1\n2\n"+strings.Repeat(thinRule, 4)+"\nc\n", buf.String())
+}
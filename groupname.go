@@ -0,0 +1,46 @@
+package console
+
+import (
+	"strings"
+	"unicode"
+)
+
+// GroupNamePolicy is how WithGroup handles group names, see HandlerOptions.GroupNames.
+type GroupNamePolicy int
+
+const (
+	// GroupNamesTrim trims spaces from the ends of group names, and passes them through
+	// otherwise, so a name like "a.b" can't be told apart from group "b" in group "a", and
+	// an empty name makes key paths like "a..b".
+	GroupNamesTrim GroupNamePolicy = iota
+	// GroupNamesSanitize trims spaces from the ends of group names, replaces dots and
+	// spaces within them with "_", and ignores groups whose names are empty after
+	// trimming, as slog.Handler specifies, so each group path is unambiguous.
+	GroupNamesSanitize
+)
+
+// groupName returns the name of a group as set by GroupNames, and false if the group
+// should be ignored.
+func (h *Handler) groupName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if h.opts.GroupNames != GroupNamesSanitize {
+		return name, true
+	}
+	if name == "" {
+		return "", false
+	}
+	if strings.IndexFunc(name, isGroupSeparator) < 0 {
+		return name, true
+	}
+	return strings.Map(func(r rune) rune {
+		if isGroupSeparator(r) {
+			return '_'
+		}
+		return r
+	}, name), true
+}
+
+// isGroupSeparator reports whether r makes group paths ambiguous.
+func isGroupSeparator(r rune) bool {
+	return r == '.' || unicode.IsSpace(r)
+}
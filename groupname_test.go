@@ -0,0 +1,30 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_GroupNames(t *testing.T) {
+	groups := func(h slog.Handler) slog.Handler {
+		return h.WithGroup(" a ").WithGroup("").WithGroup("b.c").WithGroup("d e")
+	}
+	handlerTest{
+		opts:        HandlerOptions{NoColor: true, HeaderFormat: "%m %a"},
+		handlerFunc: groups,
+		msg:         "msg",
+		attrs:       []slog.Attr{slog.Int("n", 1)},
+		want:        "msg a..b.c.d e.n=1\n",
+	}.run(t)
+	handlerTest{
+		opts:        HandlerOptions{NoColor: true, HeaderFormat: "%m %a", GroupNames: GroupNamesSanitize},
+		handlerFunc: groups,
+		msg:         "msg",
+		attrs:       []slog.Attr{slog.Int("n", 1)},
+		want:        "msg a.b_c.d_e.n=1\n",
+	}.run(t)
+
+	// an ignored group returns the same handler
+	h := NewHandler(nil, &HandlerOptions{GroupNames: GroupNamesSanitize})
+	AssertEqual[slog.Handler](t, h, h.WithGroup("  "))
+}
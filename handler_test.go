@@ -11,7 +11,9 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -791,6 +793,100 @@ func TestHandler_ReplaceAttr(t *testing.T) {
 
 }
 
+func TestHandler_ReplaceAttrContext(t *testing.T) {
+	var calls []string
+	redacted := map[string]bool{"token": true}
+	replaceAttr := func(groups []string, a slog.Attr) slog.Attr {
+		calls = append(calls, strings.Join(append(slices.Clone(groups), a.Key), "."))
+		if redacted[a.Key] {
+			return slog.String(a.Key, "***")
+		}
+		return a
+	}
+
+	for _, perRecord := range []bool{false, true} {
+		t.Run(fmt.Sprint("perRecord=", perRecord), func(t *testing.T) {
+			calls = nil
+			redacted = map[string]bool{"token": true}
+			buf := bytes.Buffer{}
+			h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", ReplaceAttr: replaceAttr, ReplaceAttrPerRecord: perRecord})
+			l := slog.New(h).With("token", "a").WithGroup("req").With("token", "b", "id", 1)
+			AssertEqual(t, "", buf.String())
+
+			l.Info("m", "x", 2)
+			AssertEqual(t, "m token=*** req.token=*** req.id=1 req.x=2\n", buf.String())
+
+			// ReplaceAttr changes its mind
+			buf.Reset()
+			redacted = map[string]bool{"id": true}
+			l.Info("m")
+			if perRecord {
+				AssertEqual(t, "m token=a req.token=b req.id=***\n", buf.String())
+				AssertEqual(t, "token req.token req.id req.x msg token req.token req.id msg", strings.Join(calls, " "))
+			} else {
+				AssertEqual(t, "m token=*** req.token=*** req.id=1\n", buf.String())
+				AssertEqual(t, "token req.token req.id req.x msg msg", strings.Join(calls, " "))
+			}
+		})
+	}
+}
+
+func TestHandler_ReplaceGroupAttrs(t *testing.T) {
+	var seen []string
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		seen = append(seen, strings.Join(append(groups, a.Key), "."))
+		switch a.Key {
+		case "secrets":
+			return slog.Attr{}
+		case "req":
+			return slog.Attr{Key: "request", Value: a.Value}
+		case "flat":
+			return slog.String("flat", "replaced")
+		}
+		return a
+	}
+
+	tests := []handlerTest{
+		{
+			name: "drop group",
+			attrs: []slog.Attr{
+				slog.Group("secrets", slog.String("password", "hunter2")),
+				slog.String("foo", "bar"),
+			},
+			want: "INF groups foo=bar\n",
+		},
+		{
+			name: "rename group",
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("method", "GET")),
+			},
+			want: "INF groups request.method=GET\n",
+		},
+		{
+			name: "replace group with value",
+			attrs: []slog.Attr{
+				slog.Group("flat", slog.String("a", "b")),
+			},
+			want: "INF groups flat=replaced\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "groups"
+		tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", ReplaceAttr: replace, ReplaceGroupAttrs: true}
+		t.Run(tt.name, tt.run)
+	}
+
+	seen = nil
+	handlerTest{
+		msg:   "groups",
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", ReplaceAttr: replace},
+		attrs: []slog.Attr{slog.Group("secrets", slog.String("password", "hunter2"))},
+		want:  "INF groups secrets.password=hunter2\n",
+	}.run(t)
+	AssertEqual(t, "secrets.password,level,msg", strings.Join(seen, ","))
+}
+
 func TestHandler_TruncateSourcePath(t *testing.T) {
 	origCwd := cwd
 	t.Cleanup(func() { cwd = origCwd })
@@ -1264,15 +1360,15 @@ func TestHandler_HeaderFormat(t *testing.T) {
 		},
 		{
 			name:  "invalid right align modifier",
-			opts:  HandlerOptions{HeaderFormat: "%m %-L %a", NoColor: true},
+			opts:  HandlerOptions{HeaderFormat: "%m %-t %a", NoColor: true},
 			attrs: []slog.Attr{slog.String("foo", "bar")},
-			want:  "with headers %!-(INVALID_MODIFIER)L foo=bar\n",
+			want:  "with headers %!-(INVALID_MODIFIER)t foo=bar\n",
 		},
 		{
 			name:  "invalid width modifier",
-			opts:  HandlerOptions{HeaderFormat: "%m %43L %a", NoColor: true},
+			opts:  HandlerOptions{HeaderFormat: "%m %43t %a", NoColor: true},
 			attrs: []slog.Attr{slog.String("foo", "bar")},
-			want:  "with headers %!43(INVALID_MODIFIER)L foo=bar\n",
+			want:  "with headers %!43(INVALID_MODIFIER)t foo=bar\n",
 		},
 		{
 			name:  "invalid style modifier",
@@ -1352,6 +1448,92 @@ func TestHandler_HeaderFormat(t *testing.T) {
 			},
 			want: "ERR+1 ERROR+1 >\n",
 		},
+		{
+			name: "numeric level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%l L=%n >"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelWarn + 1
+			},
+			want: "WRN+1 L=5 >\n",
+		},
+		{
+			name: "negative numeric level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%n >"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelDebug
+			},
+			want: "-4 >\n",
+		},
+		{
+			name: "numeric level with ReplaceAttr",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%n >", ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.LevelKey {
+					return slog.Any(slog.LevelKey, slog.LevelError)
+				}
+				return a
+			}},
+			want: "8 >\n",
+		},
+		{
+			name: "single-character level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%c >"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelWarn + 1
+			},
+			want: "W >\n",
+		},
+		{
+			name: "single-character debug level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%c >"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelDebug - 2
+			},
+			want: "D >\n",
+		},
+		{
+			name: "inverse single-character level",
+			opts: HandlerOptions{HeaderFormat: "%c", InverseLevelBadge: true, Theme: NewDefaultTheme()},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelError
+			},
+			want: styled("E", NewDefaultTheme().LevelError+ToANSICode(Inverse)) + "\n",
+		},
+		{
+			name: "inverse ignored without color",
+			opts: HandlerOptions{HeaderFormat: "%c", InverseLevelBadge: true, NoColor: true},
+			want: "I\n",
+		},
+		{
+			name: "padded full level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%7L|"},
+			want: "INFO   |\n",
+		},
+		{
+			name: "padded full level with offset",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%7L|"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelInfo + 2
+			},
+			want: "INFO+2 |\n",
+		},
+		{
+			name: "right aligned level",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%-5l|"},
+			want: "  INF|\n",
+		},
+		{
+			name: "levels are not truncated",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%3L|"},
+			recFunc: func(r *slog.Record) {
+				r.Level = slog.LevelError + 4
+			},
+			want: "ERROR+4|\n",
+		},
+		{
+			name: "padding is outside colors",
+			opts: HandlerOptions{HeaderFormat: "%-6L|", Theme: NewDefaultTheme()},
+			want: "  " + styled("INFO", NewDefaultTheme().LevelInfo) + styled("|", NewDefaultTheme().Header) + "\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1578,3 +1760,428 @@ func TestThemes(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Accessors(t *testing.T) {
+	lvl := &slog.LevelVar{}
+	lvl.Set(slog.LevelWarn)
+	h := NewHandler(io.Discard, &HandlerOptions{Level: lvl, NoColor: true})
+
+	AssertEqual(t, slog.LevelWarn, h.Level())
+	lvl.Set(slog.LevelDebug)
+	AssertEqual(t, slog.LevelDebug, h.Level())
+
+	h2 := h.WithAttrs([]slog.Attr{slog.String("a", "1")}).
+		WithGroup("g").
+		WithAttrs([]slog.Attr{slog.String("b", "2")}).
+		WithGroup("h").(*Handler)
+
+	AssertEqual(t, 0, len(h.Groups()))
+	AssertEqual(t, "g,h", strings.Join(h2.Groups(), ","))
+
+	attrs := h2.ContextAttrs()
+	AssertEqual(t, 2, len(attrs))
+	AssertEqual(t, true, attrs[0].Equal(slog.String("a", "1")))
+	AssertEqual(t, true, attrs[1].Equal(slog.Group("g", slog.String("b", "2"))))
+	AssertEqual(t, 0, len(h.ContextAttrs()))
+
+	opts := h2.Options()
+	AssertEqual(t, true, opts.NoColor)
+	AssertEqual(t, time.DateTime, opts.TimeFormat)
+	opts.NoColor = false
+	AssertEqual(t, true, h2.Options().NoColor)
+}
+
+func TestHandler_ResolveAttrs(t *testing.T) {
+	var seenGroups []string
+	h := NewHandler(io.Discard, &HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			seenGroups = append(seenGroups, strings.Join(groups, ".")+":"+a.Key)
+			switch a.Key {
+			case "secret":
+				return slog.Attr{}
+			case "n":
+				return slog.Int("n", int(a.Value.Int64())*2)
+			}
+			return a
+		},
+	})
+	h2 := h.WithAttrs([]slog.Attr{slog.String("a", "1")}).
+		WithGroup("g").
+		WithAttrs([]slog.Attr{slog.String("secret", "x")}).(*Handler)
+
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	rec.AddAttrs(
+		slog.Int("n", 2),
+		slog.Group("empty", slog.String("secret", "y")),
+		slog.Group("", slog.String("inline", "z")),
+		slog.Any("valuer", &theValuer{"v"}),
+	)
+
+	seenGroups = nil
+	attrs := h2.ResolveAttrs(rec)
+	AssertEqual(t, 2, len(attrs))
+	AssertEqual(t, true, attrs[0].Equal(slog.String("a", "1")))
+	AssertEqual(t, true, attrs[1].Equal(slog.Group("g", slog.Int("n", 4), slog.String("inline", "z"), slog.String("valuer", "The word is 'v'"))))
+	AssertEqual(t, ":a,g:secret,g:n,g.empty:secret,g:inline,g:valuer", strings.Join(seenGroups, ","))
+}
+
+func TestHandler_ResolveRecord(t *testing.T) {
+	h := NewHandler(io.Discard, &HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Time(a.Key, a.Value.Time().Add(time.Hour))
+			case slog.LevelKey:
+				return slog.Any(a.Key, slog.LevelError)
+			case slog.MessageKey:
+				return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+			case "password":
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+	}).WithAttrs([]slog.Attr{slog.String("a", "1")}).(*Handler)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rec := slog.NewRecord(ts, slog.LevelInfo, "msg", 42)
+	rec.AddAttrs(slog.String("password", "hunter2"))
+
+	r2 := h.ResolveRecord(rec)
+	AssertEqual(t, ts.Add(time.Hour), r2.Time)
+	AssertEqual(t, slog.LevelError, r2.Level)
+	AssertEqual(t, "MSG", r2.Message)
+	AssertEqual(t, uintptr(42), r2.PC)
+	var attrs []string
+	r2.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a.String())
+		return true
+	})
+	AssertEqual(t, "a=1,password=***", strings.Join(attrs, ","))
+
+	// the original is unchanged
+	AssertEqual(t, 1, rec.NumAttrs())
+	AssertEqual(t, "msg", rec.Message)
+}
+
+func TestHandler_ResolveRecordElided(t *testing.T) {
+	h := NewHandler(io.Discard, &HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.MessageKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	r2 := h.ResolveRecord(slog.NewRecord(time.Now(), slog.LevelWarn, "msg", 0))
+	AssertEqual(t, true, r2.Time.IsZero())
+	AssertEqual(t, "", r2.Message)
+	AssertEqual(t, slog.LevelWarn, r2.Level)
+}
+
+func TestHandler_WithGroupKeepsMultilineContext(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%m %a"},
+		msg:  "msg",
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("foo", "a\nb")}).WithGroup("g")
+		},
+		want: "msg\n=== foo ===\na\nb\n",
+	}.run(t)
+}
+
+func TestHandler_WithGroupDoesNotShareGroups(t *testing.T) {
+	h := NewHandler(io.Discard, &HandlerOptions{NoColor: true}).WithGroup("a").WithGroup("b").(*Handler)
+	h1 := h.WithGroup("c").(*Handler)
+	h2 := h.WithGroup("d").(*Handler)
+	AssertEqual(t, "a,b,c", strings.Join(h1.Groups(), ","))
+	AssertEqual(t, "a,b,d", strings.Join(h2.Groups(), ","))
+}
+
+func TestHandler_WithHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		with   func(h *Handler) slog.Handler
+		attrs  []slog.Attr
+		want   string
+	}{
+		{
+			name:   "inserted before message",
+			format: "%l %m %a",
+			with: func(h *Handler) slog.Handler {
+				return h.WithHeaders("request_id", "user")
+			},
+			attrs: []slog.Attr{slog.String("user", "bob"), slog.String("request_id", "123"), slog.String("foo", "bar")},
+			want:  "INF 123 bob msg foo=bar\n",
+		},
+		{
+			name:   "missing header is elided",
+			format: "%l %m %a",
+			with: func(h *Handler) slog.Handler {
+				return h.WithHeaders("request_id")
+			},
+			want: "INF msg\n",
+		},
+		{
+			name:   "ordered with existing headers",
+			format: "%[a]h %m %[c]h",
+			with: func(h *Handler) slog.Handler {
+				return h.WithHeaders("b")
+			},
+			attrs: []slog.Attr{slog.String("c", "3"), slog.String("b", "2"), slog.String("a", "1")},
+			want:  "1 2 msg 3\n",
+		},
+		{
+			name:   "existing header ignored",
+			format: "%l %[a]h > %m %a",
+			with: func(h *Handler) slog.Handler {
+				return h.WithHeaders("a")
+			},
+			attrs: []slog.Attr{slog.String("a", "1")},
+			want:  "INF 1 > msg\n",
+		},
+		{
+			name:   "no message",
+			format: "%l %a",
+			with: func(h *Handler) slog.Handler {
+				return h.WithHeaders("a")
+			},
+			attrs: []slog.Attr{slog.String("a", "1"), slog.String("b", "2")},
+			want:  "INF 1 b=2\n",
+		},
+		{
+			name:   "context attrs moved to headers",
+			format: "%l %m %a",
+			with: func(h *Handler) slog.Handler {
+				h2 := h.WithAttrs([]slog.Attr{slog.String("request_id", "123"), slog.String("foo", "bar")}).WithGroup("g").WithAttrs([]slog.Attr{slog.String("user", "bob")})
+				return h2.(*Handler).WithHeaders("request_id", "g.user")
+			},
+			attrs: []slog.Attr{slog.String("baz", "qux")},
+			want:  "INF 123 bob msg foo=bar g.baz=qux\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			h := tt.with(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: tt.format}))
+			rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+			rec.AddAttrs(tt.attrs...)
+			AssertNoError(t, h.Handle(context.Background(), rec))
+			AssertEqual(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestHandler_SetTheme(t *testing.T) {
+	var buf bytes.Buffer
+	def, bright := NewDefaultTheme(), NewBrightTheme()
+	h := NewHandler(&buf, &HandlerOptions{Theme: def, HeaderFormat: "%m %[id]h %a"})
+	h2 := h.WithAttrs([]slog.Attr{slog.String("a", "1"), slog.String("id", "x")})
+
+	line := func(theme Theme) string {
+		return styled("msg", theme.Message) + " " + styled("x", theme.Header) + " " +
+			styled("a=", theme.AttrKey) + styled("1", theme.AttrValue) + "\n"
+	}
+
+	AssertNoError(t, h2.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	AssertEqual(t, line(def), buf.String())
+
+	buf.Reset()
+	h.SetTheme(bright)
+	AssertNoError(t, h2.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	AssertEqual(t, line(bright), buf.String())
+	AssertEqual(t, bright.Name, h2.(*Handler).Options().Theme.Name)
+
+	// handlers derived after the switch use the new theme too
+	buf.Reset()
+	h3 := h2.WithGroup("g")
+	AssertNoError(t, h3.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	AssertEqual(t, line(bright), buf.String())
+}
+
+func TestHandler_ThemeValueKinds(t *testing.T) {
+	theme := NewDefaultTheme()
+	theme.AttrValueTime = ToANSICode(Blue)
+	theme.AttrValueDuration = ToANSICode(Magenta)
+	theme.AttrValueBool = ToANSICode(Yellow)
+	theme.AttrValueNumber = ToANSICode(Cyan)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	attr := func(k, v string, style ANSIMod) string {
+		return " " + styled(k+"=", theme.AttrKey) + styled(v, style)
+	}
+	handlerTest{
+		opts: HandlerOptions{Theme: theme, HeaderFormat: "%a", TimeFormat: time.RFC3339},
+		attrs: []slog.Attr{
+			slog.Time("t", at), slog.Duration("d", time.Second), slog.Bool("b", true),
+			slog.Int("i", 1), slog.Float64("f", 1.5), slog.String("s", "x"),
+		},
+		want: strings.TrimPrefix(attr("t", "2024-01-02T03:04:05Z", theme.AttrValueTime)+
+			attr("d", "1s", theme.AttrValueDuration)+attr("b", "true", theme.AttrValueBool)+
+			attr("i", "1", theme.AttrValueNumber)+attr("f", "1.5", theme.AttrValueNumber)+
+			attr("s", "x", theme.AttrValue), " ") + "\n",
+	}.run(t)
+
+	// unset kinds fall back to AttrValue
+	theme = NewDefaultTheme()
+	theme.AttrValue = ToANSICode(Green)
+	handlerTest{
+		opts:  HandlerOptions{Theme: theme, HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Bool("b", true)},
+		want:  styled("b=", theme.AttrKey) + styled("true", theme.AttrValue) + "\n",
+	}.run(t)
+}
+
+func TestHandler_SetThemeConcurrent(t *testing.T) {
+	h := NewHandler(io.Discard, &HandlerOptions{Theme: NewDefaultTheme()})
+	logger := slog.New(h).With("a", 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("msg", "j", j)
+				logger.With("b", 2).Info("msg")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			h.SetTheme(NewBrightTheme())
+		} else {
+			h.SetTheme(NewDefaultTheme())
+		}
+	}
+	wg.Wait()
+}
+
+func TestHandler_NumericAllocs(t *testing.T) {
+	type statusCode int
+	h := NewHandler(io.Discard, &HandlerOptions{})
+	empty := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	rec := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	rec.AddAttrs(
+		slog.Int("int", -12), slog.Uint64("uint", 12), slog.Float64("float", 1.5),
+		slog.Any("int8", int8(3)), slog.Any("status", statusCode(200)),
+		slog.Bool("bool", true), slog.Duration("dur", time.Second),
+	)
+	base := testing.AllocsPerRun(100, func() { _ = h.Handle(context.Background(), empty) })
+	allocs := testing.AllocsPerRun(100, func() { _ = h.Handle(context.Background(), rec) })
+	AssertEqual(t, base, allocs)
+
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Any("status", statusCode(200))},
+		want:  "INF msg status=200\n",
+	}.run(t)
+}
+
+func TestHandler_SetNoColor(t *testing.T) {
+	var buf bytes.Buffer
+	opts := HandlerOptions{
+		Theme:        NewDefaultTheme(),
+		HeaderFormat: "%m %[id]h %e %a",
+		AttrOrder:    []string{"b"},
+	}
+	ctxAttrs := []slog.Attr{
+		slog.String("a", "1"), slog.String("id", "x"), slog.Any("err", errors.New("boom")),
+		slog.Group("g", slog.Int("b", 2)), slog.String("multi", "one\ntwo"),
+	}
+	h := NewHandler(&buf, &opts)
+	h2 := h.WithAttrs(ctxAttrs).WithGroup("req").WithAttrs([]slog.Attr{slog.Int("b", 3)})
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	rec.AddAttrs(slog.Duration("dur", time.Second))
+
+	var want bytes.Buffer
+	noColorOpts := opts
+	noColorOpts.NoColor = true
+	AssertNoError(t, NewHandler(&want, &noColorOpts).WithAttrs(ctxAttrs).WithGroup("req").WithAttrs([]slog.Attr{slog.Int("b", 3)}).Handle(context.Background(), rec))
+
+	AssertNoError(t, h2.Handle(context.Background(), rec))
+	colored := buf.String()
+	AssertEqual(t, true, colored != want.String())
+
+	for i := 0; i < 2; i++ {
+		buf.Reset()
+		h.SetNoColor(true)
+		AssertNoError(t, h2.Handle(context.Background(), rec))
+		AssertEqual(t, want.String(), buf.String())
+
+		buf.Reset()
+		h.SetNoColor(false)
+		AssertNoError(t, h2.Handle(context.Background(), rec))
+		AssertEqual(t, colored, buf.String())
+	}
+}
+
+func TestHandler_Now(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := HandlerOptions{NoColor: true, HeaderFormat: "%t %m", TimeFormat: time.RFC3339, Now: func() time.Time { return now }}
+	handlerTest{
+		opts: opts,
+		msg:  "zero time",
+		want: "2024-01-02T03:04:05Z zero time\n",
+	}.run(t)
+	handlerTest{
+		opts: opts,
+		msg:  "record time",
+		time: now.Add(time.Hour),
+		want: "2024-01-02T04:04:05Z record time\n",
+	}.run(t)
+}
+
+type countingValuer struct{ calls *int }
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.calls++
+	return slog.IntValue(*v.calls)
+}
+
+func TestHandler_WithOptions(t *testing.T) {
+	buf := bytes.Buffer{}
+	var calls int
+	h := NewHandler(&buf, &HandlerOptions{HeaderFormat: "%m %a"})
+	h2 := h.WithGroup("req").WithAttrs([]slog.Attr{
+		slog.Any("n", countingValuer{&calls}),
+		slog.Group("g", slog.Any("m", countingValuer{&calls})),
+	}).(*Handler).WithTenant("acme")
+
+	plain := h2.WithOptions(&HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"})
+	AssertNoError(t, plain.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelWarn, "hi", 0)))
+	AssertEqual(t, "[acme] WRN hi req.n=1 req.g.m=2\n", buf.String())
+	AssertEqual(t, "req", strings.Join(plain.Groups(), "."))
+
+	// the context was resolved once, when it was added
+	buf.Reset()
+	slog.New(h2).Info("hi")
+	h2.SetTheme(NewBrightTheme())
+	slog.New(h2).Info("hi")
+	AssertEqual(t, 2, calls)
+	AssertEqual(t, 2, strings.Count(buf.String(), "req.n="))
+
+	// it shares the counts
+	warnings, _ := h.Counts()
+	AssertEqual(t, 1, warnings)
+}
+
+func FuzzHandler_Values(f *testing.F) {
+	f.Add("k", "v", int64(1))
+	f.Add("", "multi\nline", int64(-5))
+	f.Add("a.b", "\x1b[31mred\x1b[0m", int64(0))
+	f.Add("key with spaces", "tab\there", int64(1<<40))
+	f.Fuzz(func(t *testing.T, key, value string, n int64) {
+		var buf bytes.Buffer
+		h := NewHandler(&buf, &HandlerOptions{NoColor: true, DisablePooling: true, AddSource: true, HeaderFormat: "%l %m %a"})
+		slog.New(h).With(key, n).WithGroup("g").Info(value, key, value, "d", time.Duration(n))
+		out := buf.String()
+		if !strings.HasSuffix(out, "\n") {
+			t.Fatalf("output isn't terminated: %q", out)
+		}
+		if !strings.Contains(key+value, "\x1b") && strings.Contains(out, "\x1b") {
+			t.Fatalf("NoColor output has escape sequences: %q", out)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package console
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// idleMonitor prints a marker line when nothing has been written for
+// HandlerOptions.IdleMarker.
+type idleMonitor struct {
+	// time of the last line written, other than markers, in unix nanos
+	lastWrite atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	// now and newTimer are the clock, replaced in tests.  newTimer returns the timer's
+	// channel, and a func which stops it.
+	now      func() time.Time
+	newTimer func(time.Duration) (<-chan time.Time, func() bool)
+}
+
+func newIdleMonitor() *idleMonitor {
+	m := &idleMonitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		now:  time.Now,
+		newTimer: func(d time.Duration) (<-chan time.Time, func() bool) {
+			t := time.NewTimer(d)
+			return t.C, t.Stop
+		},
+	}
+	m.touch()
+	return m
+}
+
+func (m *idleMonitor) touch() {
+	m.lastWrite.Store(m.now().UnixNano())
+}
+
+// close stops the monitor, and waits for it to exit.
+func (m *idleMonitor) close() {
+	close(m.stop)
+	<-m.done
+}
+
+// watchIdle prints a marker each time the output has been idle for another multiple of
+// threshold, until the monitor is closed.
+func (h *Handler) watchIdle(m *idleMonitor, threshold time.Duration) {
+	defer close(m.done)
+	wait := threshold
+	var seen int64
+	var reported time.Duration
+	for {
+		c, stop := m.newTimer(wait)
+		select {
+		case <-m.stop:
+			stop()
+			return
+		case <-c:
+		}
+		last := m.lastWrite.Load()
+		if last != seen {
+			// there's been output since the last marker
+			seen = last
+			reported = 0
+		}
+		idle := m.now().Sub(time.Unix(0, last))
+		n := idle / threshold
+		if n > 0 && n*threshold > reported {
+			reported = n * threshold
+			h.current().writeIdleMarker(reported)
+		}
+		wait = (n+1)*threshold - idle
+	}
+}
+
+// writeIdleMarker writes a dim line like "— no output for 5m —".
+func (h *Handler) writeIdleMarker(idle time.Duration) {
+	enc := newEncoder(h)
+	enc.idleMarker = true
+	enc.writeColoredString(&enc.buf, "— no output for "+shortDuration(idle)+" —", ToANSICode(Faint))
+	// errors are reported by OnWriteError and WriteFailure
+	_ = h.write(context.Background(), enc)
+}
+
+// shortDuration formats d without trailing zero units, like "5m" instead of "5m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
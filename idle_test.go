@@ -0,0 +1,139 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while the handler writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// fakeClock drives an idleMonitor: each timer the monitor starts is reported on
+// timers, and fires when the test calls advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	t      time.Time
+	timers chan time.Duration
+	fire   chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1000, 0), timers: make(chan time.Duration), fire: make(chan time.Time)}
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) newTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.timers <- d
+	return c.fire, func() bool { return true }
+}
+
+// advance moves the clock forward by d, and fires the pending timer.  It returns
+// once the monitor has started its next timer, with the duration of that timer.
+func (c *fakeClock) advance(t *testing.T, d time.Duration) time.Duration {
+	t.Helper()
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+	c.fire <- c.now()
+	return c.wait(t)
+}
+
+// wait returns the duration of the timer the monitor starts next.
+func (c *fakeClock) wait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.timers:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the idle monitor")
+		return 0
+	}
+}
+
+// idleHandler returns a handler with an idle monitor driven by a fake clock.
+func idleHandler(t *testing.T, w *syncBuffer, threshold time.Duration) (*Handler, *fakeClock) {
+	clock := newFakeClock()
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m"})
+	m := newIdleMonitor()
+	m.now, m.newTimer = clock.now, clock.newTimer
+	m.touch()
+	h.shared.idle = m
+	go h.watchIdle(m, threshold)
+	AssertEqual(t, threshold, clock.wait(t))
+	return h, clock
+}
+
+func TestHandler_IdleMarker(t *testing.T) {
+	const threshold = 20 * time.Millisecond
+	var buf syncBuffer
+	h, clock := idleHandler(t, &buf, threshold)
+	slog.New(h).Info("hi")
+
+	AssertEqual(t, threshold, clock.advance(t, threshold))
+	// timers firing late wait only for the rest of the next multiple
+	AssertEqual(t, threshold/4, clock.advance(t, threshold+3*threshold/4))
+	// firing early prints nothing, and waits for the rest again
+	AssertEqual(t, threshold/4, clock.advance(t, 0))
+	AssertNoError(t, h.Close())
+	AssertEqual(t, "hi\n— no output for 20ms —\n— no output for 40ms —\n", buf.String())
+
+	// stopped by Close
+	select {
+	case d := <-clock.timers:
+		t.Fatalf("timer started after Close: %v", d)
+	default:
+	}
+}
+
+func TestHandler_IdleMarkerReset(t *testing.T) {
+	const threshold = 40 * time.Millisecond
+	var buf syncBuffer
+	h, clock := idleHandler(t, &buf, threshold)
+	defer h.Close()
+	l := slog.New(h)
+	// output more often than the threshold keeps the marker away
+	l.Info("hi")
+	for i := 0; i < 4; i++ {
+		clock.mu.Lock()
+		clock.t = clock.t.Add(threshold / 2)
+		clock.mu.Unlock()
+		l.Info("hi")
+		AssertEqual(t, threshold/2, clock.advance(t, threshold/2))
+	}
+	AssertEqual(t, "hi\nhi\nhi\nhi\nhi\n", buf.String())
+
+	// the count restarts after output
+	AssertEqual(t, threshold, clock.advance(t, threshold/2))
+	AssertEqual(t, "hi\nhi\nhi\nhi\nhi\n— no output for 40ms —\n", buf.String())
+}
+
+func TestShortDuration(t *testing.T) {
+	AssertEqual(t, "5m", shortDuration(5*time.Minute))
+	AssertEqual(t, "1h", shortDuration(time.Hour))
+	AssertEqual(t, "1h30m", shortDuration(90*time.Minute))
+	AssertEqual(t, "1m30s", shortDuration(90*time.Second))
+	AssertEqual(t, "20ms", shortDuration(20*time.Millisecond))
+}
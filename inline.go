@@ -0,0 +1,149 @@
+package console
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// InlineCollisionPolicy is how attrs in inline groups, groups with an empty key, are
+// printed when their keys collide with the keys of the attrs around the group, see
+// HandlerOptions.InlineCollisions.
+type InlineCollisionPolicy int
+
+const (
+	// InlineCollisionsKeep prints both attrs, with the same key.
+	InlineCollisionsKeep InlineCollisionPolicy = iota
+	// InlineCollisionsSuffix prints the inline group's attr with a numbered suffix, like
+	// "id#2=7".
+	InlineCollisionsSuffix
+	// InlineCollisionsOverride prints the inline group's value in place of the other
+	// attr's, like "id=7".
+	InlineCollisionsOverride
+	// InlineCollisionsMark prints both, with inlineCollisionMarker after the inline group's
+	// key, like "id=3 id^=7".
+	InlineCollisionsMark
+)
+
+// inlineCollisionMarker is printed after the keys of inline group attrs which collide.
+const inlineCollisionMarker = "^"
+
+// encodeRecordAttrs encodes the record's attrs, with inline groups handled as set by
+// InlineCollisions.
+func (e *encoder) encodeRecordAttrs(rec slog.Record) {
+	e.recordAttrs = true
+	defer func() { e.recordAttrs = false }()
+	if e.h.opts.InlineCollisions != InlineCollisionsKeep {
+		attrs := make([]slog.Attr, 0, rec.NumAttrs())
+		rec.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		for _, a := range expandInline(attrs, e.h.opts.InlineCollisions) {
+			e.encodeAttr(e.h.groupPrefix, a)
+		}
+		return
+	}
+	rec.Attrs(func(a slog.Attr) bool {
+		e.encodeAttr(e.h.groupPrefix, a)
+		return true
+	})
+}
+
+// expandInline returns attrs with their inline groups expanded, and the keys of inline
+// attrs which collide with the other attrs handled as set by policy.  Groups are
+// expanded too, since each group is a level of keys of its own.
+func expandInline(attrs []slog.Attr, policy InlineCollisionPolicy) []slog.Attr {
+	var hasInline bool
+	// keys of the attrs outside inline groups, and their index in out
+	outer := make(map[string]int, len(attrs))
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			if a.Key == "" {
+				hasInline = true
+				out = append(out, a)
+				continue
+			}
+			a.Value = slog.GroupValue(expandInline(a.Value.Group(), policy)...)
+		}
+		if _, ok := outer[a.Key]; !ok {
+			outer[a.Key] = len(out)
+		}
+		out = append(out, a)
+	}
+	if !hasInline {
+		return out
+	}
+
+	taken := make(map[string]bool, len(out))
+	for k := range outer {
+		taken[k] = true
+	}
+	overrides := map[int]slog.Value{}
+	var inline []slog.Attr
+	// keys of the inline attrs which didn't collide, and their index in inline
+	inlineKeys := map[string]int{}
+	var add func(attrs []slog.Attr)
+	add = func(attrs []slog.Attr) {
+		for _, a := range attrs {
+			a.Value = a.Value.Resolve()
+			if a.Value.Kind() == slog.KindGroup {
+				if a.Key == "" {
+					add(a.Value.Group())
+					continue
+				}
+				a.Value = slog.GroupValue(expandInline(a.Value.Group(), policy)...)
+			}
+			i, collides := outer[a.Key]
+			j, collidesInline := inlineKeys[a.Key]
+			if !collides && !collidesInline {
+				taken[a.Key] = true
+				inlineKeys[a.Key] = len(inline)
+				inline = append(inline, a)
+				continue
+			}
+			switch {
+			case policy == InlineCollisionsOverride && collides:
+				overrides[i] = a.Value
+				continue
+			case policy == InlineCollisionsOverride:
+				inline[j].Value = a.Value
+				continue
+			case policy == InlineCollisionsMark:
+				a.Key += inlineCollisionMarker
+			default:
+				n := 2
+				for taken[a.Key+"#"+strconv.Itoa(n)] {
+					n++
+				}
+				a.Key += "#" + strconv.Itoa(n)
+			}
+			taken[a.Key] = true
+			inline = append(inline, a)
+		}
+	}
+	// expand all the inline groups first, since they can override attrs after them
+	var ends []int
+	for _, a := range out {
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			add(a.Value.Group())
+			ends = append(ends, len(inline))
+		}
+	}
+
+	expanded := make([]slog.Attr, 0, len(out)+len(inline))
+	var start int
+	for i, a := range out {
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			expanded = append(expanded, inline[start:ends[0]]...)
+			start, ends = ends[0], ends[1:]
+			continue
+		}
+		if v, ok := overrides[i]; ok {
+			a.Value = v
+		}
+		expanded = append(expanded, a)
+	}
+	return expanded
+}
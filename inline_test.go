@@ -0,0 +1,87 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_InlineGroupInGroup(t *testing.T) {
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%m %a"},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Group("req", slog.Group("", slog.Int("x", 1)), slog.Int("y", 2))},
+		want:  "msg req.x=1 req.y=2\n",
+	}.run(t)
+}
+
+func TestHandler_InlineCollisions(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Int("id", 3),
+		slog.Group("", slog.Int("id", 7), slog.String("name", "a")),
+		slog.Group("", slog.Int("id", 8)),
+		slog.Group("req", slog.Group("", slog.String("method", "PUT")), slog.String("method", "GET")),
+		slog.Int("id#2", 0),
+	}
+	tests := []struct {
+		policy InlineCollisionPolicy
+		want   string
+	}{
+		{InlineCollisionsKeep, "msg id=3 id=7 name=a id=8 req.method=PUT req.method=GET id#2=0\n"},
+		{InlineCollisionsSuffix, "msg id=3 id#3=7 name=a id#4=8 req.method#2=PUT req.method=GET id#2=0\n"},
+		{InlineCollisionsOverride, "msg id=8 name=a req.method=PUT id#2=0\n"},
+		{InlineCollisionsMark, "msg id=3 id^=7 name=a id^=8 req.method^=PUT req.method=GET id#2=0\n"},
+	}
+	for _, tt := range tests {
+		handlerTest{
+			opts:  HandlerOptions{NoColor: true, HeaderFormat: "%m %a", InlineCollisions: tt.policy},
+			msg:   "msg",
+			attrs: attrs,
+			want:  tt.want,
+		}.run(t)
+	}
+
+	// sorted
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%m %a", InlineCollisions: InlineCollisionsMark, SortAttrs: true},
+		msg:   "msg",
+		attrs: attrs[:2],
+		want:  "msg id=3 id^=7 name=a\n",
+	}.run(t)
+}
+
+func TestHandler_InlineCollisionsBetweenGroups(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Group("", slog.Int("id", 7), slog.String("name", "a")),
+		slog.Group("", slog.Int("id", 8)),
+	}
+	tests := []struct {
+		policy InlineCollisionPolicy
+		want   string
+	}{
+		{InlineCollisionsKeep, "msg id=7 name=a id=8\n"},
+		{InlineCollisionsSuffix, "msg id=7 name=a id#2=8\n"},
+		{InlineCollisionsOverride, "msg id=8 name=a\n"},
+		{InlineCollisionsMark, "msg id=7 name=a id^=8\n"},
+	}
+	for _, tt := range tests {
+		handlerTest{
+			opts:  HandlerOptions{NoColor: true, HeaderFormat: "%m %a", InlineCollisions: tt.policy},
+			msg:   "msg",
+			attrs: attrs,
+			want:  tt.want,
+		}.run(t)
+	}
+}
+
+func TestHandler_InlineCollisionsContext(t *testing.T) {
+	// keys added with WithAttrs aren't compared
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%m %a", InlineCollisions: InlineCollisionsSuffix},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.Int("id", 3)})
+		},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.Group("", slog.Int("id", 7))},
+		want:  "msg id=3 id=7\n",
+	}.run(t)
+}
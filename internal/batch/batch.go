@@ -0,0 +1,107 @@
+// Package batch collects items into batches, and pushes them when a batch is full or
+// has waited long enough.
+package batch
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Batcher collects items, and passes them to a push function in batches.  A batch is
+// pushed when it reaches the batch size, when the wait interval elapses, or when Flush
+// or Close is called.  Pushes are serialized.
+type Batcher[T any] struct {
+	push    func([]T) error
+	size    int
+	onError func(error)
+
+	mu    sync.Mutex
+	items []T
+
+	// pushMu serializes pushes
+	pushMu sync.Mutex
+
+	full      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// New returns a Batcher which pushes batches of up to size items, at least every wait.
+// Errors from pushes made in the background are passed to onError, if it's not nil.
+func New[T any](size int, wait time.Duration, push func([]T) error, onError func(error)) *Batcher[T] {
+	b := &Batcher[T]{
+		push:    push,
+		size:    size,
+		onError: onError,
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.run(wait)
+	return b
+}
+
+func (b *Batcher[T]) run(wait time.Duration) {
+	defer close(b.stopped)
+	t := time.NewTicker(wait)
+	defer t.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-t.C:
+		case <-b.full:
+		}
+		if err := b.Flush(); err != nil && b.onError != nil {
+			b.onError(err)
+		}
+	}
+}
+
+// Add adds an item to the current batch.
+func (b *Batcher[T]) Add(item T) {
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	full := len(b.items) >= b.size
+	b.mu.Unlock()
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush pushes the current batch, if it's not empty, and waits for the push to finish.
+// Items added since the last push are split into batches of at most the batch size.
+func (b *Batcher[T]) Flush() error {
+	b.pushMu.Lock()
+	defer b.pushMu.Unlock()
+
+	b.mu.Lock()
+	items := b.items
+	b.items = nil
+	b.mu.Unlock()
+
+	var errs []error
+	for len(items) > 0 {
+		n := min(len(items), b.size)
+		if err := b.push(items[:n:n]); err != nil {
+			errs = append(errs, err)
+		}
+		items = items[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops pushing in the background, and pushes the current batch.  Items
+// added after Close are only pushed by calls to Flush.
+func (b *Batcher[T]) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		<-b.stopped
+	})
+	return b.Flush()
+}
@@ -0,0 +1,76 @@
+package batch
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	pushed  chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{pushed: make(chan struct{}, 10)}
+}
+
+func (r *recorder) push(items []int) error {
+	r.mu.Lock()
+	r.batches = append(r.batches, items)
+	r.mu.Unlock()
+	r.pushed <- struct{}{}
+	return nil
+}
+
+func (r *recorder) get() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestBatcher_Size(t *testing.T) {
+	r := newRecorder()
+	b := New(2, time.Hour, r.push, nil)
+	b.Add(1)
+	b.Add(2)
+	<-r.pushed
+	b.Add(3)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]int{{1, 2}, {3}}; !reflect.DeepEqual(want, r.get()) {
+		t.Errorf("expected %v, got %v", want, r.get())
+	}
+}
+
+func TestBatcher_Wait(t *testing.T) {
+	r := newRecorder()
+	b := New(100, 10*time.Millisecond, r.push, nil)
+	defer b.Close()
+	b.Add(1)
+	<-r.pushed
+	if want := [][]int{{1}}; !reflect.DeepEqual(want, r.get()) {
+		t.Errorf("expected %v, got %v", want, r.get())
+	}
+}
+
+func TestBatcher_Errors(t *testing.T) {
+	errBoom := errors.New("boom")
+	errs := make(chan error, 1)
+	b := New(1, time.Hour, func([]int) error { return errBoom }, func(err error) { errs <- err })
+	b.Add(1)
+	if err := <-errs; !errors.Is(err, errBoom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("expected no error closing an empty batch, got %v", err)
+	}
+	b.Add(2)
+	if err := b.Flush(); !errors.Is(err, errBoom) {
+		t.Errorf("expected boom from Flush, got %v", err)
+	}
+}
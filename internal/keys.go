@@ -0,0 +1,9 @@
+package internal
+
+// JoinKey joins key to its group prefix with ".".
+func JoinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,93 @@
+package console
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// parsePlaceholders collects the keys of the "{key}" placeholders in msg.
+func (e *encoder) parsePlaceholders(msg string) {
+	for {
+		start := strings.IndexByte(msg, '{')
+		if start < 0 {
+			return
+		}
+		end := strings.IndexByte(msg[start:], '}')
+		if end < 0 {
+			return
+		}
+		key := msg[start+1 : start+end]
+		if key == "" || strings.ContainsAny(key, "{ ") {
+			// not a placeholder, keep looking after the opening brace
+			msg = msg[start+1:]
+			continue
+		}
+		e.placeholders = append(e.placeholders, key)
+		e.placeholderAttrs = append(e.placeholderAttrs, slog.Attr{})
+		msg = msg[start+end+1:]
+	}
+}
+
+// capturePlaceholder stores a if its key matches one of the placeholders, and
+// reports whether it did.
+func (e *encoder) capturePlaceholder(groupPrefix string, a slog.Attr) bool {
+	key := a.Key
+	if groupPrefix != "" {
+		key = groupPrefix + "." + a.Key
+	}
+	rel := key
+	if e.h.groupPrefix != "" {
+		rel = strings.TrimPrefix(key, e.h.groupPrefix+".")
+	}
+
+	var captured bool
+	for i, p := range e.placeholders {
+		if p == key || p == rel {
+			e.placeholderAttrs[i] = a
+			captured = true
+		}
+	}
+	return captured
+}
+
+// interpolate replaces the placeholders in msg with the values of the captured attrs.
+func (e *encoder) interpolate(msg string) string {
+	var b buffer
+	for i, p := range e.placeholders {
+		placeholder := "{" + p + "}"
+		idx := strings.Index(msg, placeholder)
+		if idx < 0 {
+			continue
+		}
+		b.AppendString(msg[:idx])
+		if a := e.placeholderAttrs[i]; a.Equal(slog.Attr{}) {
+			b.AppendString(placeholder)
+		} else {
+			e.writeValue(&b, a.Value)
+		}
+		msg = msg[idx+len(placeholder):]
+	}
+	b.AppendString(msg)
+	return b.String()
+}
+
+// recordValue marks the values of the record's own attrs when they're sorted together
+// with the context's, see HandlerOptions.SortAttrs, so they're still the only ones
+// substituted into placeholders.  encodeAttr unwraps it.
+type recordValue struct{ slog.Value }
+
+// markRecordAttrs returns a copy of attrs with their values, and the values in their
+// groups, wrapped in recordValue.
+func markRecordAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(markRecordAttrs(a.Value.Group())...)
+		} else {
+			a.Value = slog.AnyValue(recordValue{a.Value})
+		}
+		out[i] = a
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHandler_InterpolateMessage(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "substitutes and removes attrs",
+			msg:   "copied {count} files to {dest}",
+			attrs: []slog.Attr{slog.Int("count", 3), slog.String("dest", "/tmp"), slog.Duration("took", time.Second)},
+			want:  "INF copied 3 files to /tmp took=1s\n",
+		},
+		{
+			name:  "keep attrs",
+			opts:  HandlerOptions{KeepInterpolatedAttrs: true},
+			msg:   "copied {count} files",
+			attrs: []slog.Attr{slog.Int("count", 3)},
+			want:  "INF copied 3 files count=3\n",
+		},
+		{
+			name:  "missing attrs are left as-is",
+			msg:   "hello {name}, {greeting}",
+			attrs: []slog.Attr{slog.String("name", "bob")},
+			want:  "INF hello bob, {greeting}\n",
+		},
+		{
+			name:  "repeated placeholders",
+			msg:   "{a} and {a}",
+			attrs: []slog.Attr{slog.String("a", "x")},
+			want:  "INF x and x\n",
+		},
+		{
+			name:  "groups",
+			msg:   "{req.method} {req.path}",
+			attrs: []slog.Attr{slog.Group("req", slog.String("method", "GET"), slog.String("path", "/"))},
+			want:  "INF GET /\n",
+		},
+		{
+			name: "relative to handler groups",
+			msg:  "{user} and {srv.user}",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("srv")
+			},
+			attrs: []slog.Attr{slog.String("user", "bob")},
+			want:  "INF bob and bob\n",
+		},
+		{
+			name:  "not placeholders",
+			msg:   "{} { a } {{b}",
+			attrs: []slog.Attr{slog.String("b", "x")},
+			want:  "INF {} { a } {x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.opts.InterpolateMessage = true
+		tt.opts.NoColor = true
+		tt.opts.HeaderFormat = "%l %m %a"
+		t.Run(tt.name, tt.run)
+	}
+
+	handlerTest{
+		name:  "disabled",
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"},
+		msg:   "copied {count} files",
+		attrs: []slog.Attr{slog.Int("count", 3)},
+		want:  "INF copied {count} files count=3\n",
+	}.run(t)
+}
+
+func TestHandler_InterpolateMessageContext(t *testing.T) {
+	tests := []handlerTest{
+		{name: "default"},
+		{name: "sorted", opts: HandlerOptions{SortAttrs: true}},
+		{name: "replace attr per record", opts: HandlerOptions{ReplaceAttrPerRecord: true}},
+	}
+	for _, tt := range tests {
+		tt.opts.InterpolateMessage = true
+		tt.opts.NoColor = true
+		tt.opts.HeaderFormat = "%m %a"
+		tt.handlerFunc = func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("user", "bob")})
+		}
+		tt.msg = "hello {user} {x}"
+		tt.attrs = []slog.Attr{slog.Int("x", 1), slog.Group("z", slog.Int("y", 2))}
+		tt.want = "hello {user} 1 user=bob z.y=2\n"
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -0,0 +1,71 @@
+package console
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// keyCacheSize is the number of slots in the key cache.  It's a power of two.
+const keyCacheSize = 128
+
+// keyCache caches the rendered form of attribute keys: a space, the styled key,
+// with its group prefix, and "=".  It's direct mapped: each key maps to one slot,
+// found with a cheap hash, so lookups cost an atomic load and a string compare,
+// and the cache never grows.  A key which collides with another replaces it.
+type keyCache [keyCacheSize]atomic.Pointer[keyCacheEntry]
+
+type keyCacheEntry struct {
+	// themeGen is the theme generation the key was rendered with
+	themeGen   uint64
+	group, key string
+	rendered   string
+}
+
+// slot picks the cache slot for a key.  It only looks at the lengths and a few
+// bytes, which is enough to spread typical keys.
+func (c *keyCache) slot(group, key string) *atomic.Pointer[keyCacheEntry] {
+	h := uint(len(key))*31 + uint(len(group))*17
+	if len(key) > 0 {
+		h += uint(key[0])*7 + uint(key[len(key)-1])*3 + uint(key[len(key)/2])
+	}
+	if len(group) > 0 {
+		h += uint(group[len(group)-1]) * 13
+	}
+	return &c[h%keyCacheSize]
+}
+
+// writeKey writes a space and the attribute key, joined to its group prefix, and
+// "=", to buf.
+func (e *encoder) writeKey(buf *buffer, group, key string) {
+	if e.h.opts.DisableKeyCache {
+		e.renderKey(buf, group, key)
+		return
+	}
+	slot := e.h.keys.slot(group, key)
+	if ent := slot.Load(); ent != nil && ent.themeGen == e.h.themeGen && ent.key == key && ent.group == group {
+		buf.AppendString(ent.rendered)
+		return
+	}
+	start := len(*buf)
+	e.renderKey(buf, group, key)
+	// the strings are cloned, so group, which is often built on the stack, doesn't
+	// have to escape to the heap
+	slot.Store(&keyCacheEntry{
+		themeGen: e.h.themeGen,
+		group:    strings.Clone(group),
+		key:      strings.Clone(key),
+		rendered: string((*buf)[start:]),
+	})
+}
+
+func (e *encoder) renderKey(buf *buffer, group, key string) {
+	buf.AppendByte(' ')
+	e.withColor(buf, e.h.opts.Theme.AttrKey, func() {
+		if group != "" {
+			buf.AppendString(group)
+			buf.AppendByte('.')
+		}
+		buf.AppendString(key)
+		buf.AppendByte('=')
+	})
+}
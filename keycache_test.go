@@ -0,0 +1,43 @@
+package console
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHandler_KeyCache(t *testing.T) {
+	theme := NewDefaultTheme()
+	for _, disable := range []bool{false, true} {
+		h := NewHandler(nil, &HandlerOptions{Theme: theme, DisableKeyCache: disable})
+		for i := 0; i < 2; i++ {
+			enc := newEncoder(h)
+			enc.writeKey(&enc.attrBuf, "req", "status")
+			enc.writeKey(&enc.attrBuf, "", "status")
+			AssertEqual(t, " "+styled("req.status=", theme.AttrKey)+" "+styled("status=", theme.AttrKey), enc.attrBuf.String())
+			enc.free()
+		}
+		cached := 0
+		for i := range h.keys {
+			if h.keys[i].Load() != nil {
+				cached++
+			}
+		}
+		if disable {
+			AssertEqual(t, 0, cached)
+		} else {
+			AssertEqual(t, 2, cached)
+		}
+	}
+}
+
+func TestHandler_KeyCacheUnbounded(t *testing.T) {
+	// colliding keys replace each other, and are still rendered correctly
+	h := NewHandler(nil, &HandlerOptions{NoColor: true})
+	for i := 0; i < 2*keyCacheSize; i++ {
+		k := "k" + strconv.Itoa(i)
+		enc := newEncoder(h)
+		enc.writeKey(&enc.attrBuf, "g", k)
+		AssertEqual(t, " g."+k+"=", enc.attrBuf.String())
+		enc.free()
+	}
+}
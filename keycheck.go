@@ -0,0 +1,69 @@
+package console
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SnakeCaseKeys is a KeyPattern for lowercase snake_case keys, like "user_id".
+var SnakeCaseKeys = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// KeyViolationMode is how keys which don't match HandlerOptions.KeyPattern are handled.
+type KeyViolationMode int
+
+const (
+	// KeyViolationFlag prints the key with keyViolationMarker after it, like "userID!=42".
+	KeyViolationFlag KeyViolationMode = iota
+	// KeyViolationRewrite rewrites the key in snake_case, like "user_id=42".  If the
+	// rewritten key still doesn't match KeyPattern, it's flagged.
+	KeyViolationRewrite
+)
+
+// keyViolationMarker is printed after keys which don't match KeyPattern.
+const keyViolationMarker = "!"
+
+// checkKey returns the key, rewritten or flagged if it doesn't match KeyPattern.
+func (e *encoder) checkKey(key string) string {
+	if e.h.opts.KeyPattern.MatchString(key) {
+		return key
+	}
+	if e.h.opts.KeyViolation == KeyViolationRewrite {
+		if k := snakeCase(key); e.h.opts.KeyPattern.MatchString(k) {
+			return k
+		}
+	}
+	return key + keyViolationMarker
+}
+
+// snakeCase rewrites key in lowercase snake_case: words are split at spaces,
+// punctuation, and changes from lower to upper case, like "userID" to "user_id",
+// and "HTTPServer-name" to "http_server_name".
+func snakeCase(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	underscore := false
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			// start a new word before an upper case letter which follows a lower case
+			// letter or digit, or which starts a word after an acronym, like the S in
+			// "HTTPServer"
+			if i > 0 && b.Len() > 0 && !underscore && (!unicode.IsUpper(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			underscore = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			underscore = false
+		default:
+			if b.Len() > 0 && !underscore {
+				b.WriteByte('_')
+				underscore = true
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,49 @@
+package console
+
+import (
+	"log/slog"
+	"regexp"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"user_id":         "user_id",
+		"userID":          "user_id",
+		"UserId":          "user_id",
+		"HTTPServer-name": "http_server_name",
+		"user name":       "user_name",
+		"  trim me  ":     "trim_me",
+		"a.b":             "a_b",
+		"v2Count":         "v2_count",
+	}
+	for in, want := range tests {
+		AssertEqual(t, want, snakeCase(in))
+	}
+}
+
+func TestHandler_KeyPattern(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Int("user_id", 1),
+		slog.Int("userID", 2),
+		slog.Group("Req Info", slog.String("Method", "GET")),
+	}
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", KeyPattern: SnakeCaseKeys},
+		attrs: attrs,
+		want:  "user_id=1 userID!=2 Req Info!.Method!=GET\n",
+	}.run(t)
+
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", KeyPattern: SnakeCaseKeys, KeyViolation: KeyViolationRewrite},
+		attrs: attrs,
+		want:  "user_id=1 user_id=2 req_info.method=GET\n",
+	}.run(t)
+
+	// keys which can't be rewritten to match are flagged
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", KeyPattern: regexp.MustCompile(`^[a-z]+$`), KeyViolation: KeyViolationRewrite},
+		attrs: []slog.Attr{slog.Int("userID", 2)},
+		want:  "userID!=2\n",
+	}.run(t)
+}
@@ -0,0 +1,47 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+)
+
+// PrintLegend prints a line listing the levels, as they're printed in records, with the
+// handler's Theme, HeaderFormat and ReplaceAttr, like:
+//
+//	levels: DBG INF WRN ERR
+//
+// so CLIs can show what each level looks like at startup, e.g. after parsing -v flags,
+// without the legend drifting from the real output.  Without levels, it lists the standard
+// levels the handler is enabled for.  Levels which ReplaceAttr elides are left out.
+func (h *Handler) PrintLegend(levels ...slog.Level) error {
+	h = h.current()
+	if len(levels) == 0 {
+		for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+			if l >= h.opts.Level.Level() {
+				levels = append(levels, l)
+			}
+		}
+	}
+
+	// print the levels the way the first level field does, but unpadded
+	var f levelField
+	for _, field := range h.fields {
+		if lf, ok := field.(levelField); ok {
+			f = lf
+			break
+		}
+	}
+	f.width = 0
+
+	enc := newEncoder(h)
+	enc.writeColoredString(&enc.buf, "levels:", h.opts.Theme.Header)
+	for _, l := range levels {
+		n := len(enc.buf)
+		enc.buf.AppendByte(' ')
+		if enc.encodeLevel(l, f); len(enc.buf) == n+1 {
+			// elided
+			enc.buf = enc.buf[:n]
+		}
+	}
+	return h.write(context.Background(), enc)
+}
@@ -0,0 +1,44 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_PrintLegend(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true})
+	AssertNoError(t, h.PrintLegend())
+	AssertEqual(t, "levels: INF WRN ERR\n", buf.String())
+
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{NoColor: true, Level: slog.LevelDebug, HeaderFormat: "%5L %m"})
+	AssertNoError(t, h.PrintLegend())
+	AssertEqual(t, "levels: DEBUG INFO WARN ERROR\n", buf.String())
+
+	// custom levels, renamed with ReplaceAttr
+	const levelTrace = slog.LevelDebug - 4
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{
+		NoColor: true,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			switch {
+			case a.Key != slog.LevelKey:
+			case a.Value.Any() == levelTrace:
+				return slog.String(a.Key, "TRC")
+			case a.Value.Any() == slog.LevelWarn:
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	AssertNoError(t, h.PrintLegend(levelTrace, slog.LevelInfo, slog.LevelWarn, slog.LevelError+2))
+	AssertEqual(t, "levels: TRC INF ERR+2\n", buf.String())
+
+	theme := NewDefaultTheme()
+	buf.Reset()
+	h = NewHandler(&buf, &HandlerOptions{Theme: theme})
+	AssertNoError(t, h.PrintLegend(slog.LevelInfo, slog.LevelError))
+	AssertEqual(t, styled("levels:", theme.Header)+" "+styled("INF", theme.LevelInfo)+" "+styled("ERR", theme.LevelError)+"\n", buf.String())
+}
@@ -0,0 +1,33 @@
+package console
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// levelPrefixes maps lower-cased message prefixes to levels.
+var levelPrefixes = map[string]slog.Level{
+	"error":   slog.LevelError,
+	"err":     slog.LevelError,
+	"warning": slog.LevelWarn,
+	"warn":    slog.LevelWarn,
+	"info":    slog.LevelInfo,
+	"debug":   slog.LevelDebug,
+}
+
+// levelFromPrefix parses a level name followed by a colon from the start of msg,
+// like "ERROR: disk full".  It returns the level, and the rest of the message
+// with the prefix and following whitespace removed.
+func levelFromPrefix(msg string) (slog.Level, string, bool) {
+	trimmed := strings.TrimLeft(msg, " \t")
+	// longest prefix is "warning:"
+	idx := strings.IndexByte(trimmed[:min(len(trimmed), len("warning:"))], ':')
+	if idx < 1 {
+		return 0, msg, false
+	}
+	l, ok := levelPrefixes[strings.ToLower(trimmed[:idx])]
+	if !ok {
+		return 0, msg, false
+	}
+	return l, strings.TrimLeft(trimmed[idx+1:], " \t"), true
+}
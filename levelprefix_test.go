@@ -0,0 +1,85 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLevelFromPrefix(t *testing.T) {
+	tests := []struct {
+		msg     string
+		level   slog.Level
+		rest    string
+		matched bool
+	}{
+		{"ERROR: disk full", slog.LevelError, "disk full", true},
+		{"err:disk full", slog.LevelError, "disk full", true},
+		{"  warning:  low memory", slog.LevelWarn, "low memory", true},
+		{"Warn: low memory", slog.LevelWarn, "low memory", true},
+		{"INFO: started", slog.LevelInfo, "started", true},
+		{"debug: x=1", slog.LevelDebug, "x=1", true},
+		{"errors: 3", 0, "errors: 3", false},
+		{"note: hi", 0, "note: hi", false},
+		{": hi", 0, ": hi", false},
+		{"ERROR", 0, "ERROR", false},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			l, rest, ok := levelFromPrefix(tt.msg)
+			AssertEqual(t, tt.matched, ok)
+			AssertEqual(t, tt.level, l)
+			AssertEqual(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestHandler_LevelFromMessagePrefix(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name: "corrects level",
+			opts: HandlerOptions{LevelFromMessagePrefix: true},
+			msg:  "ERROR: disk full",
+			want: "ERR ERROR: disk full\n",
+		},
+		{
+			name: "strips prefix",
+			opts: HandlerOptions{LevelFromMessagePrefix: true, StripLevelPrefix: true},
+			msg:  "warning: low memory",
+			want: "WRN low memory\n",
+		},
+		{
+			name: "drops records corrected below the level",
+			opts: HandlerOptions{LevelFromMessagePrefix: true, Level: slog.LevelInfo},
+			msg:  "debug: noisy",
+			want: "",
+		},
+		{
+			name: "disabled",
+			msg:  "ERROR: disk full",
+			want: "INF ERROR: disk full\n",
+		},
+	}
+	for _, tt := range tests {
+		tt.opts.NoColor = true
+		tt.opts.HeaderFormat = "%l %m"
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_LevelFromMessagePrefix_ContextLevel(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m", LevelFromMessagePrefix: true, Level: slog.LevelError})
+
+	ctx := WithMinLevel(context.Background(), slog.LevelDebug)
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelError, "debug: noisy", 0)))
+	AssertEqual(t, "DBG debug: noisy\n", buf.String())
+
+	buf.Reset()
+	ctx = WithMinLevel(context.Background(), slog.LevelInfo)
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelError, "debug: noisy", 0)))
+	AssertEqual(t, "", buf.String())
+}
@@ -0,0 +1,115 @@
+package console
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// lineBufferSize is how much a LineBufferedWriter buffers before writing
+	// the complete lines it holds, without waiting for lineFlushDelay.
+	lineBufferSize = 64 * 1024
+
+	// lineFlushDelay is the longest a complete line waits in a
+	// LineBufferedWriter before it's written.
+	lineFlushDelay = 50 * time.Millisecond
+)
+
+// LineBufferedWriter buffers writes, and writes them to the underlying writer a line at a
+// time, so a line is never split between writes.  If the underlying writer is a terminal,
+// complete lines are written right away.  Otherwise, like when stdout is a pipe, lines are
+// collected and written together, which is much faster than a write per line, but each
+// complete line is still written within 50ms.  A partial line is held until it's completed,
+// or Flush is called.
+//
+// Call Flush before exiting, to write whatever is buffered.  Handler.Flush calls it when the
+// LineBufferedWriter is the handler's output.
+type LineBufferedWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tty   bool
+	buf   []byte
+	timer *time.Timer
+	err   error // error from a background write, returned by the next call
+}
+
+// NewLineBufferedWriter returns a LineBufferedWriter which writes to w, typically os.Stdout.
+func NewLineBufferedWriter(w io.Writer) *LineBufferedWriter {
+	return &LineBufferedWriter{w: w, tty: isTerminal(w)}
+}
+
+// Write buffers p.  It returns an error from an earlier write to the underlying writer, if
+// any, or from writing complete lines to it now.
+func (w *LineBufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.takeErr(); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf, p...)
+	if w.tty || len(w.buf) >= lineBufferSize {
+		if err := w.writeLines(); err != nil {
+			return len(p), err
+		}
+	}
+	if w.timer == nil && bytes.IndexByte(w.buf, '\n') >= 0 {
+		w.timer = time.AfterFunc(lineFlushDelay, w.flushLines)
+	}
+	return len(p), nil
+}
+
+// Flush writes everything buffered, including a partial line, to the underlying writer.
+func (w *LineBufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.takeErr(); err != nil {
+		return err
+	}
+	w.stopTimer()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// flushLines is called by the timer to write the complete lines.
+func (w *LineBufferedWriter) flushLines() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	if err := w.writeLines(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeLines writes the complete lines in the buffer, keeping a trailing
+// partial line.
+func (w *LineBufferedWriter) writeLines() error {
+	end := bytes.LastIndexByte(w.buf, '\n') + 1
+	if end == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf[:end])
+	w.buf = w.buf[:copy(w.buf, w.buf[end:])]
+	if bytes.IndexByte(w.buf, '\n') < 0 {
+		w.stopTimer()
+	}
+	return err
+}
+
+func (w *LineBufferedWriter) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func (w *LineBufferedWriter) takeErr() error {
+	err := w.err
+	w.err = nil
+	return err
+}
@@ -0,0 +1,89 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lockedBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes++
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLineBufferedWriter(t *testing.T) {
+	var dst lockedBuffer
+	w := NewLineBufferedWriter(&dst)
+
+	for _, s := range []string{"one\n", "two\n", "thr"} {
+		n, err := w.Write([]byte(s))
+		AssertNoError(t, err)
+		AssertEqual(t, len(s), n)
+	}
+	// nothing written yet
+	AssertEqual(t, "", dst.String())
+
+	// complete lines are written after the delay, in one write
+	deadline := time.Now().Add(5 * time.Second)
+	for dst.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	AssertEqual(t, "one\ntwo\n", dst.String())
+	AssertEqual(t, 1, dst.writes)
+
+	// the partial line is written by Flush
+	_, _ = w.Write([]byte("ee"))
+	AssertNoError(t, w.Flush())
+	AssertEqual(t, "one\ntwo\nthree", dst.String())
+	AssertEqual(t, 2, dst.writes)
+}
+
+func TestLineBufferedWriter_Full(t *testing.T) {
+	var dst lockedBuffer
+	w := NewLineBufferedWriter(&dst)
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < lineBufferSize/len(line); i++ {
+		_, _ = w.Write([]byte(line))
+	}
+	// a full buffer is written right away
+	AssertEqual(t, lineBufferSize, len(dst.String()))
+	AssertNoError(t, w.Flush())
+}
+
+func TestLineBufferedWriter_Err(t *testing.T) {
+	boom := errors.New("boom")
+	w := NewLineBufferedWriter(writerFunc(func([]byte) (int, error) { return 0, boom }))
+	_, err := w.Write([]byte("partial"))
+	AssertNoError(t, err)
+	AssertEqual(t, boom, w.Flush())
+}
+
+func TestLineBufferedWriter_Handler(t *testing.T) {
+	var dst lockedBuffer
+	w := NewLineBufferedWriter(&dst)
+	h := NewHandler(w, &HandlerOptions{NoColor: true})
+	l := slog.New(h)
+	l.Info("one")
+	l.Info("two")
+	AssertNoError(t, h.Flush())
+	AssertEqual(t, 1, dst.writes)
+	AssertEqual(t, true, strings.HasSuffix(dst.String(), "INF two\n"))
+}
@@ -0,0 +1,16 @@
+package console
+
+import "log/slog"
+
+// Printer formats values for a locale, see HandlerOptions.Locale.  A *message.Printer from
+// golang.org/x/text/message is a Printer, so this package doesn't depend on x/text:
+//
+//	opts.Locale = message.NewPrinter(language.German) // 1234567.5 prints 1.234.567,5
+type Printer interface {
+	Sprint(a ...any) string
+}
+
+// writeLocaleNumber writes the int, uint, or float value with the Locale.
+func (e *encoder) writeLocaleNumber(buf *buffer, value slog.Value) {
+	buf.AppendString(e.h.opts.Locale.Sprint(value.Any()))
+}
@@ -0,0 +1,41 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// germanPrinter formats numbers like message.NewPrinter(language.German).
+type germanPrinter struct{}
+
+func (germanPrinter) Sprint(a ...any) string {
+	s := fmt.Sprint(a...)
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && r != '-' && (len(intPart)-i)%3 == 0 && intPart[i-1] != '-' {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if hasFrac {
+		b.WriteString("," + frac)
+	}
+	return b.String()
+}
+
+func TestHandler_Locale(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%a", Locale: germanPrinter{}, PercentKeys: map[string]int{"p": 1}},
+		attrs: []slog.Attr{
+			slog.Int("i", -1234567),
+			slog.Uint64("u", 1000),
+			slog.Float64("f", 1234.5),
+			slog.Float64("p", 0.5),
+			slog.String("s", "1234"),
+		},
+		want: "i=-1.234.567 u=1.000 f=1.234,5 p=50.0% s=1234\n",
+	}.run(t)
+}
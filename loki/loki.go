@@ -0,0 +1,342 @@
+// Package loki pushes logs to Grafana Loki, rendered the same way as the console
+// handler renders them.
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	console "github.com/ansel1/console-slog"
+	"github.com/ansel1/console-slog/internal"
+	"github.com/ansel1/console-slog/internal/batch"
+)
+
+// Options are options for a Sink.
+type Options struct {
+	// URL is Loki's push endpoint, like "http://localhost:3100/loki/api/v1/push".
+	URL string
+
+	// Labels are added to every stream.
+	Labels map[string]string
+
+	// LabelKeys are the keys of attrs whose values are used as labels.  Keys of attrs in
+	// groups are joined with ".".  Attrs are matched after HandlerOptions.ReplaceAttr is
+	// applied.  Label names are the keys, with characters which aren't
+	// allowed in label names replaced with "_".  A "level" label is always added.
+	LabelKeys []string
+
+	// BatchSize is the maximum number of records pushed at once.  Defaults to 100.
+	BatchSize int
+
+	// BatchWait is the longest time a record waits before it's pushed.  Defaults to 1s.
+	BatchWait time.Duration
+
+	// Client is used to push.  Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Header is added to each push request, e.g. for authentication, or to set
+	// X-Scope-OrgID.
+	Header http.Header
+
+	// HandlerOptions configure how lines are rendered, and the minimum level of records
+	// pushed to Loki.  Color is always disabled.
+	HandlerOptions *console.HandlerOptions
+
+	// OnError is called with errors from pushes made in the background.
+	OnError func(error)
+}
+
+// Sink batches records and pushes them to Loki.
+type Sink struct {
+	opts Options
+	// resolver resolves the attrs of records once, for both the line and the labels
+	resolver *console.Handler
+	// render renders the resolved records.  It has no context, and only applies
+	// ReplaceAttr to the builtin attrs, which the resolver doesn't resolve.
+	render  *console.Handler
+	batcher *batch.Batcher[entry]
+}
+
+type entry struct {
+	labels []label
+	time   time.Time
+	line   string
+}
+
+type label struct {
+	name, value string
+}
+
+// New returns a Sink pushing to the Loki API at opts.URL.  Close the sink before exiting,
+// to push pending records.
+func New(opts Options) *Sink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.BatchWait <= 0 {
+		opts.BatchWait = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	var hopts console.HandlerOptions
+	if opts.HandlerOptions != nil {
+		hopts = *opts.HandlerOptions
+	}
+	hopts.NoColor = true
+	// the handlers only render into io.Discard, and are never closed
+	hopts.Banner, hopts.IdleMarker, hopts.PrintSummary = nil, 0, false
+
+	ropts := hopts
+	// the resolver's env attrs are in the resolved attrs
+	ropts.EnvPrefix = ""
+	if replace := hopts.ReplaceAttr; replace != nil {
+		ropts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if v, ok := a.Value.Any().(resolvedValue); ok {
+				a.Value = v.value
+				return a
+			}
+			if a.Value.Kind() == slog.KindGroup {
+				return a
+			}
+			return replace(groups, a)
+		}
+	}
+
+	s := &Sink{
+		opts:     opts,
+		resolver: console.NewHandler(io.Discard, &hopts),
+		render:   console.NewHandler(io.Discard, &ropts),
+	}
+	s.batcher = batch.New(opts.BatchSize, opts.BatchWait, s.push, opts.OnError)
+	return s
+}
+
+// Middleware returns a console.Middleware which pushes records to Loki, as well as passing
+// them to the next handler, so one logger can write pretty logs to the console and ship them
+// to Loki:
+//
+//	sink := loki.New(loki.Options{URL: "http://localhost:3100/loki/api/v1/push", LabelKeys: []string{"service"}})
+//	defer sink.Close()
+//	logger := slog.New(console.Chain(console.NewHandler(os.Stderr, nil), sink.Middleware()))
+func (s *Sink) Middleware() console.Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &handler{next: next, sink: s, resolver: s.resolver}
+	}
+}
+
+// Flush pushes pending records, and waits for the push to finish.
+func (s *Sink) Flush() error {
+	return s.batcher.Flush()
+}
+
+// Close stops pushing in the background, and pushes pending records.
+func (s *Sink) Close() error {
+	return s.batcher.Close()
+}
+
+type handler struct {
+	next     slog.Handler
+	sink     *Sink
+	resolver *console.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.resolver.Enabled(ctx, l) || h.next.Enabled(ctx, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if !h.resolver.Enabled(ctx, r.Level) {
+		return err
+	}
+
+	// the attrs are resolved once, so ReplaceAttr sees each attr once, and the line and the
+	// labels see the same values
+	attrs := h.resolver.ResolveAttrs(r)
+	resolved := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if h.sink.opts.HandlerOptions != nil && h.sink.opts.HandlerOptions.ReplaceAttr != nil {
+		resolved.AddAttrs(markResolved(attrs)...)
+	} else {
+		resolved.AddAttrs(attrs...)
+	}
+	rctx, capture := console.WithCapture(ctx)
+	if rerr := h.sink.render.Handle(rctx, resolved); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+
+	// labels come from the attrs as the console handler sees them, after ReplaceAttr
+	var labels []label
+	for _, a := range attrs {
+		labels = h.sink.appendLabels(labels, "", a)
+	}
+	labels = append(labels, label{"level", strings.ToLower(r.Level.String())})
+	h.sink.batcher.Add(entry{
+		labels: labels,
+		time:   r.Time,
+		line:   strings.TrimSuffix(capture.String(), "\n"),
+	})
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.resolver = h.resolver.WithAttrs(attrs).(*console.Handler)
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.resolver = h.resolver.WithGroup(name).(*console.Handler)
+	return &h2
+}
+
+// Flush flushes the next handler, if it has a Flush method.  Records pushed to
+// Loki are flushed with Sink.Flush.
+func (h *handler) Flush() error {
+	if f, ok := h.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// resolvedValue marks the value of an attr ReplaceAttr has already been applied to, so the
+// sink's render handler passes it through.
+type resolvedValue struct{ value slog.Value }
+
+// markResolved returns a copy of attrs with the values of the attrs in them, and in their
+// groups, marked as resolved.
+func markResolved(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(markResolved(a.Value.Group())...)
+		} else {
+			a.Value = slog.AnyValue(resolvedValue{a.Value})
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// appendLabels appends labels for a, or the attrs in it, if their keys are LabelKeys.
+func (s *Sink) appendLabels(labels []label, prefix string, a slog.Attr) []label {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = internal.JoinKey(prefix, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			labels = s.appendLabels(labels, prefix, ga)
+		}
+		return labels
+	}
+	key := internal.JoinKey(prefix, a.Key)
+	if slices.Contains(s.opts.LabelKeys, key) {
+		labels = append(labels, label{labelName(key), a.Value.String()})
+	}
+	return labels
+}
+
+// labelName replaces characters not allowed in Loki label names with "_".
+func labelName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push sends a batch of entries to Loki, grouped into streams by their labels.
+func (s *Sink) push(entries []entry) error {
+	var req pushRequest
+	streams := map[string]int{}
+	for _, e := range entries {
+		labels := make(map[string]string, len(s.opts.Labels)+len(e.labels))
+		for k, v := range s.opts.Labels {
+			labels[k] = v
+		}
+		// later labels win, so record attrs override context attrs
+		for _, l := range e.labels {
+			labels[l.name] = l.value
+		}
+		key := streamKey(labels)
+		i, ok := streams[key]
+		if !ok {
+			i = len(req.Streams)
+			streams[key] = i
+			req.Streams = append(req.Streams, stream{Stream: labels})
+		}
+		req.Streams[i].Values = append(req.Streams[i].Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	hreq, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.opts.Header {
+		hreq.Header[k] = v
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	resp, err := s.opts.Client.Do(hreq)
+	if err != nil {
+		return fmt.Errorf("loki: push failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("loki: push failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// streamKey returns a key identifying a set of labels.
+func streamKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var sb strings.Builder
+	for _, k := range names {
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(labels[k])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
@@ -0,0 +1,224 @@
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	console "github.com/ansel1/console-slog"
+)
+
+type server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []pushRequest
+	headers  []http.Header
+	status   int
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{status: http.StatusNoContent}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req pushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, req)
+		s.headers = append(s.headers, r.Header)
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSink(t *testing.T) {
+	srv := newServer(t)
+	sink := New(Options{
+		URL:            srv.URL,
+		Labels:         map[string]string{"app": "test"},
+		LabelKeys:      []string{"service", "req.method"},
+		BatchWait:      time.Hour,
+		Header:         http.Header{"X-Scope-Orgid": {"tenant"}},
+		HandlerOptions: &console.HandlerOptions{HeaderFormat: "%l %m %a"},
+	})
+
+	var buf bytes.Buffer
+	theme := console.NewDefaultTheme()
+	h := console.Chain(console.NewHandler(&buf, &console.HandlerOptions{Theme: theme, HeaderFormat: "%m"}), sink.Middleware())
+	logger := slog.New(h).With("service", "api")
+
+	logger.Info("hello", "foo", "bar")
+	logger.WithGroup("req").Warn("slow", "method", "GET")
+	logger.Debug("dropped")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "hello") || !strings.Contains(got, "slow") {
+		t.Errorf("expected console output, got %q", got)
+	}
+	if len(srv.requests) != 1 {
+		t.Fatalf("expected 1 push, got %d", len(srv.requests))
+	}
+	if got := srv.headers[0].Get("X-Scope-OrgID"); got != "tenant" {
+		t.Errorf("expected X-Scope-OrgID header, got %q", got)
+	}
+	streams := srv.requests[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("expected 2 streams, got %+v", streams)
+	}
+	assertStream(t, streams[0], map[string]string{"app": "test", "service": "api", "level": "info"}, "INF hello service=api foo=bar")
+	assertStream(t, streams[1], map[string]string{"app": "test", "service": "api", "req_method": "GET", "level": "warn"}, "WRN slow service=api req.method=GET")
+}
+
+func assertStream(t *testing.T, s stream, labels map[string]string, line string) {
+	t.Helper()
+	if len(s.Stream) != len(labels) {
+		t.Errorf("expected labels %v, got %v", labels, s.Stream)
+	}
+	for k, v := range labels {
+		if s.Stream[k] != v {
+			t.Errorf("expected labels %v, got %v", labels, s.Stream)
+		}
+	}
+	if len(s.Values) != 1 || s.Values[0][1] != line {
+		t.Errorf("expected line %q, got %v", line, s.Values)
+	}
+}
+
+func TestSink_Batching(t *testing.T) {
+	srv := newServer(t)
+	sink := New(Options{URL: srv.URL, BatchSize: 2, BatchWait: time.Hour})
+	logger := slog.New(console.Chain(slog.NewTextHandler(&bytes.Buffer{}, nil), sink.Middleware()))
+	for i := 0; i < 3; i++ {
+		logger.Info("hello", "i", i)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var lines int
+	for _, req := range srv.requests {
+		for _, s := range req.Streams {
+			lines += len(s.Values)
+		}
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 lines pushed, got %d", lines)
+	}
+	if len(srv.requests) < 2 {
+		t.Errorf("expected at least 2 pushes, got %d", len(srv.requests))
+	}
+}
+
+func TestSink_Error(t *testing.T) {
+	srv := newServer(t)
+	srv.status = http.StatusBadRequest
+	sink := New(Options{URL: srv.URL, BatchWait: time.Hour})
+	slog.New(console.Chain(slog.NewTextHandler(&bytes.Buffer{}, nil), sink.Middleware())).Info("hello")
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected push error, got %v", err)
+	}
+}
+
+func TestLabelName(t *testing.T) {
+	for in, want := range map[string]string{
+		"service":    "service",
+		"req.method": "req_method",
+		"1st":        "_st",
+		"a-b9":       "a_b9",
+	} {
+		if got := labelName(in); got != want {
+			t.Errorf("labelName(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestSink_LabelsAfterReplaceAttr(t *testing.T) {
+	srv := newServer(t)
+	sink := New(Options{
+		URL:       srv.URL,
+		LabelKeys: []string{"service"},
+		BatchWait: time.Hour,
+		HandlerOptions: &console.HandlerOptions{
+			HeaderFormat: "%m",
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == "service" {
+					return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+				}
+				return a
+			},
+		},
+	})
+	logger := slog.New(console.Chain(slog.NewTextHandler(&bytes.Buffer{}, nil), sink.Middleware()))
+	logger.With("service", "api").Info("hello")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertStream(t, srv.requests[0].Streams[0], map[string]string{"service": "API", "level": "info"}, "hello")
+}
+
+func TestSink_ReplaceAttrOnce(t *testing.T) {
+	srv := newServer(t)
+	calls := map[string]int{}
+	sink := New(Options{
+		URL:       srv.URL,
+		LabelKeys: []string{"req.service"},
+		BatchWait: time.Hour,
+		HandlerOptions: &console.HandlerOptions{
+			HeaderFormat: "%l %m %a",
+			// not idempotent, so applying it twice shows
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				calls[a.Key]++
+				if a.Value.Kind() == slog.KindString && a.Key != slog.MessageKey {
+					return slog.String(a.Key, a.Value.String()+"!")
+				}
+				return a
+			},
+		},
+	})
+	logger := slog.New(console.Chain(slog.NewTextHandler(&bytes.Buffer{}, nil), sink.Middleware()))
+	logger.WithGroup("req").Info("hello", "service", "api", "n", 1)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertStream(t, srv.requests[0].Streams[0], map[string]string{"req_service": "api!", "level": "info"}, "INF hello req.service=api! req.n=1")
+	for _, k := range []string{slog.LevelKey, slog.MessageKey, "service", "n"} {
+		if calls[k] != 1 {
+			t.Errorf("expected ReplaceAttr to be called once for %s, got %v", k, calls)
+		}
+	}
+}
+
+func TestSink_NoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		sink := New(Options{
+			URL: "http://localhost",
+			HandlerOptions: &console.HandlerOptions{
+				IdleMarker:   time.Minute,
+				Banner:       &console.Banner{Name: "app"},
+				PrintSummary: true,
+			},
+		})
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// goroutines may take a moment to exit
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected at most %d goroutines, got %d", before, n)
+	}
+}
@@ -0,0 +1,44 @@
+package console
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+)
+
+// logValuerMemoSize is the most LogValuer results memoized by MemoizeLogValuers.  When
+// it's full, the memo is cleared, so valuers which are only used once don't leak.
+const logValuerMemoSize = 1024
+
+// logValuerMemo memoizes the results of LogValuers, for MemoizeLogValuers.
+type logValuerMemo struct {
+	mu     sync.Mutex
+	values map[slog.LogValuer]slog.Value
+}
+
+// resolve returns the resolved value of v, calling LogValue only the first time v is
+// seen.  Only pointer valuers are memoized, since other types may not be usable as map
+// keys; others are resolved each time.
+func (m *logValuerMemo) resolve(v slog.LogValuer) slog.Value {
+	if reflect.TypeOf(v).Kind() != reflect.Pointer {
+		return slog.AnyValue(v).Resolve()
+	}
+	m.mu.Lock()
+	r, ok := m.values[v]
+	m.mu.Unlock()
+	if ok {
+		return r
+	}
+	// resolved without holding the lock, since LogValue may be slow, or log
+	r = slog.AnyValue(v).Resolve()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.values) >= logValuerMemoSize {
+		clear(m.values)
+	}
+	if m.values == nil {
+		m.values = map[slog.LogValuer]slog.Value{}
+	}
+	m.values[v] = r
+	return r
+}
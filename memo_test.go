@@ -0,0 +1,52 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+type expensiveValuer struct{ calls int }
+
+func (v *expensiveValuer) LogValue() slog.Value {
+	v.calls++
+	return slog.GroupValue(slog.String("name", "app"))
+}
+
+func TestHandler_MemoizeLogValuers(t *testing.T) {
+	for _, memoize := range []bool{false, true} {
+		buf := bytes.Buffer{}
+		h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", MemoizeLogValuers: memoize})
+		cfg := &expensiveValuer{}
+		for i := 0; i < 3; i++ {
+			// like a per-request logger
+			slog.New(h).With("cfg", cfg, "i", i).Info("hi")
+		}
+		AssertEqual(t, "hi cfg.name=app i=0\nhi cfg.name=app i=1\nhi cfg.name=app i=2\n", buf.String())
+		if memoize {
+			AssertEqual(t, 1, cfg.calls)
+		} else {
+			AssertEqual(t, 3, cfg.calls)
+		}
+	}
+}
+
+func TestLogValuerMemo(t *testing.T) {
+	var m logValuerMemo
+
+	// non-pointer valuers aren't memoized
+	calls := 0
+	v := countingValuer{&calls}
+	AssertEqual(t, int64(1), m.resolve(v).Int64())
+	AssertEqual(t, int64(2), m.resolve(v).Int64())
+
+	// the memo is cleared when it's full
+	first := &expensiveValuer{}
+	m.resolve(first)
+	for i := 0; i < logValuerMemoSize; i++ {
+		m.resolve(&expensiveValuer{})
+	}
+	AssertEqual(t, 1, len(m.values))
+	m.resolve(first)
+	AssertEqual(t, 2, first.calls)
+}
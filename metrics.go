@@ -0,0 +1,61 @@
+package console
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// handlerMetrics are the counters behind Handler.Metrics.
+type handlerMetrics struct {
+	records, bytes, writeErrors, dropped atomic.Int64
+}
+
+// Metrics are counters of a handler's activity, for dashboards, and for tests which verify
+// logging behavior without scraping the output.  They're counted across the handler, and
+// all the handlers derived from it, or from the same parent.
+type Metrics struct {
+	// Records is the number of records handled.
+	Records int64
+	// Bytes is the number of bytes of records written to the output.
+	Bytes int64
+	// WriteErrors is the number of writes to the output which failed.
+	WriteErrors int64
+	// Dropped is the number of records dropped by middleware in front of the handler, like
+	// Sample, Dedup, and Throttle, plus the writes dropped by the output, if it has a
+	// Dropped method, like SocketWriter.
+	Dropped int64
+}
+
+// Metrics returns the handler's counters.
+func (h *Handler) Metrics() Metrics {
+	m := &h.shared.metrics
+	dropped := m.dropped.Load()
+	if d, ok := h.out.(interface{ Dropped() int }); ok {
+		dropped += int64(d.Dropped())
+	}
+	return Metrics{
+		Records:     m.records.Load(),
+		Bytes:       m.bytes.Load(),
+		WriteErrors: m.writeErrors.Load(),
+		Dropped:     dropped,
+	}
+}
+
+// recordDropped counts a record dropped by middleware.
+func (h *Handler) recordDropped() {
+	h.shared.metrics.dropped.Add(1)
+}
+
+// dropRecorder is implemented by handlers which count records dropped by the middleware
+// in front of them.
+type dropRecorder interface {
+	recordDropped()
+}
+
+// dropped tells next, if it counts dropped records, that middleware dropped a record.
+func dropped(next slog.Handler) error {
+	if d, ok := next.(dropRecorder); ok {
+		d.recordDropped()
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_Metrics(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"})
+	l := slog.New(Chain(h, Sample(2)))
+	for i := 0; i < 4; i++ {
+		l.With("a", 1).Info("hi")
+	}
+	AssertEqual(t, Metrics{Records: 2, Bytes: 6, Dropped: 2}, h.Metrics())
+
+	// write errors
+	fail := NewHandler(writerFunc(func(p []byte) (int, error) {
+		return 0, errors.New("boom")
+	}), &HandlerOptions{NoColor: true})
+	slog.New(fail).Info("hi")
+	AssertEqual(t, Metrics{Records: 1, WriteErrors: 1}, fail.Metrics())
+}
+
+type droppingWriter struct{ bytes.Buffer }
+
+func (w *droppingWriter) Dropped() int { return 3 }
+
+func TestHandler_MetricsWriterDropped(t *testing.T) {
+	h := NewHandler(&droppingWriter{}, nil)
+	l := slog.New(Chain(h, Dedup(0)))
+	l.Info("a")
+	AssertEqual(t, Metrics{Records: 1, Bytes: h.Metrics().Bytes, Dropped: 3}, h.Metrics())
+}
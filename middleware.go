@@ -0,0 +1,503 @@
+package console
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansel1/console-slog/internal"
+)
+
+// Middleware wraps a slog.Handler, adding behavior before records reach it.
+type Middleware func(slog.Handler) slog.Handler
+
+// Chain wraps h with each of the middlewares.  The first middleware is the outermost,
+// so it sees records first:
+//
+//	h := console.Chain(console.NewHandler(os.Stderr, nil),
+//		console.Redact("password"),
+//		console.Sample(10),
+//	)
+func Chain(h slog.Handler, middlewares ...Middleware) slog.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// RedactedValue replaces the values of redacted attrs.
+const RedactedValue = "[REDACTED]"
+
+// Redact returns a Middleware which replaces the values of attrs with any of the given keys
+// with RedactedValue.  Keys are matched at any depth inside groups.  Attrs added with
+// WithAttrs are redacted too.
+func Redact(keys ...string) Middleware {
+	return redactWith(keys, func(slog.Value) slog.Value {
+		return slog.StringValue(RedactedValue)
+	})
+}
+
+// Pseudonymize returns a Middleware which replaces the values of attrs with any of the given
+// keys with a stable short hash of the value, like "3f2a9c1d7b4e".  Equal values get equal
+// hashes, so lines about the same user can still be correlated, without exposing the raw
+// value.  The hash is an HMAC keyed with secret, so values can't be recovered by hashing
+// guesses without it.  Keys are matched at any depth inside groups, as with Redact.
+func Pseudonymize(secret []byte, keys ...string) Middleware {
+	return redactWith(keys, func(v slog.Value) slog.Value {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(v.Resolve().String()))
+		return slog.StringValue(hex.EncodeToString(mac.Sum(nil)[:6]))
+	})
+}
+
+// redactWith returns a Middleware which replaces the values of attrs with the keys.
+func redactWith(keys []string, replace func(slog.Value) slog.Value) Middleware {
+	redact := func(attrs []slog.Attr) []slog.Attr {
+		return redactAttrs(attrs, keys, replace)
+	}
+	return func(next slog.Handler) slog.Handler {
+		return &middlewareHandler{
+			next:     next,
+			mapAttrs: redact,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				return next.Handle(ctx, mapRecordAttrs(r, redact))
+			},
+		}
+	}
+}
+
+// Sample returns a Middleware which passes only one of every n records, starting with the
+// first.  The count is shared by all handlers derived from the returned handler with
+// WithAttrs and WithGroup.
+func Sample(n int) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		var count atomic.Uint64
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				if n > 1 && (count.Add(1)-1)%uint64(n) != 0 {
+					return dropped(next)
+				}
+				return next.Handle(ctx, r)
+			},
+		}
+	}
+}
+
+// SampledKey is the key of the attr SampleDebug adds to the debug records it passes.
+const SampledKey = "sampled"
+
+// sampleRand returns a random int in [0,n).  Replaced in tests.
+var sampleRand = rand.Intn
+
+// SampleDebug returns a Middleware which passes debug records with a probability of 1 in n,
+// and all records at info level and above.  The debug records which pass have an attr
+// with the key SampledKey and the sample rate as the value, like "sampled=1/10", so it's clear
+// that similar records were dropped.
+func SampleDebug(n int) Middleware {
+	rate := slog.String(SampledKey, "1/"+strconv.Itoa(n))
+	return func(next slog.Handler) slog.Handler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				if r.Level >= slog.LevelInfo || n <= 1 {
+					return next.Handle(ctx, r)
+				}
+				if sampleRand(n) != 0 {
+					return dropped(next)
+				}
+				r = r.Clone()
+				r.AddAttrs(rate)
+				return next.Handle(ctx, r)
+			},
+		}
+	}
+}
+
+// Dedup returns a Middleware which drops records with the same level and message as the
+// previous record, if they are logged within window of it.  Attrs aren't compared.  Records
+// without a time are timed when they're handled.
+func Dedup(window time.Duration) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		var mu sync.Mutex
+		var lastLevel slog.Level
+		var lastMsg string
+		var lastTime time.Time
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				t := r.Time
+				if t.IsZero() {
+					t = time.Now()
+				}
+				mu.Lock()
+				dup := r.Level == lastLevel && r.Message == lastMsg && t.Sub(lastTime) < window
+				if !dup {
+					lastLevel, lastMsg, lastTime = r.Level, r.Message, t
+				}
+				mu.Unlock()
+				if dup {
+					return dropped(next)
+				}
+				return next.Handle(ctx, r)
+			},
+		}
+	}
+}
+
+// OccurrenceKey is the key of the attr Throttle adds to the records it passes after the
+// first ones.
+const OccurrenceKey = "occurrence"
+
+// Throttle returns a Middleware which passes the first records with each level and message,
+// then only every thereafter-th one, like zap's sampler.  Counts are reset every tick,
+// measured by the records' times, and the counts of messages not seen in the last tick are
+// discarded; if tick is zero, they are never reset, and a count is kept for every distinct
+// level and message.  Records passed after the first ones have an attr with the key
+// OccurrenceKey, and the record's count in the current tick as the value, so it's clear how
+// many were dropped.  If thereafter is zero, only the first records are passed.  Attrs
+// aren't compared.  Records without a time are timed when they're handled.
+func Throttle(first, thereafter int, tick time.Duration) Middleware {
+	type counter struct {
+		count int
+		reset time.Time
+	}
+	type key struct {
+		level slog.Level
+		msg   string
+	}
+	return func(next slog.Handler) slog.Handler {
+		var mu sync.Mutex
+		counts := map[key]*counter{}
+		// when to next discard the expired counters
+		var prune time.Time
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				k := key{r.Level, r.Message}
+				t := r.Time
+				if t.IsZero() {
+					t = time.Now()
+				}
+				mu.Lock()
+				if tick > 0 && !t.Before(prune) {
+					for old, c := range counts {
+						if !t.Before(c.reset) {
+							delete(counts, old)
+						}
+					}
+					prune = t.Add(tick)
+				}
+				c := counts[k]
+				if c == nil {
+					c = &counter{}
+					counts[k] = c
+				}
+				if tick > 0 && !t.Before(c.reset) {
+					c.count = 0
+					c.reset = t.Add(tick)
+				}
+				c.count++
+				n := c.count
+				mu.Unlock()
+
+				switch {
+				case n <= first:
+					return next.Handle(ctx, r)
+				case thereafter > 0 && (n-first)%thereafter == 0:
+					r = r.Clone()
+					r.AddAttrs(slog.Int(OccurrenceKey, n))
+					return next.Handle(ctx, r)
+				}
+				return dropped(next)
+			},
+		}
+	}
+}
+
+// OnceKey is the key of the attr which marks the records Once passes only once.
+const OnceKey = "once"
+
+// onceLimit is the number of records Once remembers.
+const onceLimit = 1000
+
+// onceSeen holds the identities of the records Once has passed, for the whole process.
+// order holds the same identities, oldest first, so the oldest is forgotten once there
+// are onceLimit of them.
+var onceSeen struct {
+	sync.Mutex
+	ids   map[string]struct{}
+	order []string
+}
+
+// Once returns a Middleware which passes each record marked with an attr with the key
+// OnceKey only the first time it's logged in the process, for warnings like deprecation
+// notices, which libraries log wherever a deprecated function is called:
+//
+//	logger.Warn("Client.Do is deprecated, use Client.Send", console.OnceKey, true)
+//
+// If the attr's value is a string, it identifies the record, else records are identified
+// by their level and message.  Records are remembered across all the handlers using Once,
+// so a warning logged through several loggers is still printed only once.  The attr itself
+// is removed, and is only looked for in the record's attrs, not in groups, or in attrs
+// added with WithAttrs.  Other records pass through.
+//
+// Only the last 1000 distinct records are remembered, so a record logged again after
+// that many others may be printed again.  Messages which vary, like ones with values
+// formatted into them, should be marked with an identifying string rather than true.
+func Once() Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				var once slog.Value
+				var marked bool
+				r.Attrs(func(a slog.Attr) bool {
+					if a.Key == OnceKey {
+						once, marked = a.Value.Resolve(), true
+						return false
+					}
+					return true
+				})
+				if !marked {
+					return next.Handle(ctx, r)
+				}
+				r = mapRecordAttrs(r, func(attrs []slog.Attr) []slog.Attr {
+					return slices.DeleteFunc(attrs, func(a slog.Attr) bool { return a.Key == OnceKey })
+				})
+				if once.Kind() == slog.KindBool && !once.Bool() {
+					return next.Handle(ctx, r)
+				}
+
+				id := r.Level.String() + "\x00" + r.Message
+				if once.Kind() == slog.KindString {
+					id = "\x00" + once.String()
+				}
+				onceSeen.Lock()
+				_, seen := onceSeen.ids[id]
+				if !seen {
+					if onceSeen.ids == nil {
+						onceSeen.ids = map[string]struct{}{}
+					}
+					if len(onceSeen.order) == onceLimit {
+						delete(onceSeen.ids, onceSeen.order[0])
+						onceSeen.order = append(onceSeen.order[:0], onceSeen.order[1:]...)
+					}
+					onceSeen.ids[id] = struct{}{}
+					onceSeen.order = append(onceSeen.order, id)
+				}
+				onceSeen.Unlock()
+				if seen {
+					return dropped(next)
+				}
+				return next.Handle(ctx, r)
+			},
+		}
+	}
+}
+
+// CardinalityKey is the key of the attr CardinalityGuard adds to records and handlers with
+// values it replaced.  Its value is the full key of the replaced attr, joined to its groups
+// with ".".
+const CardinalityKey = "cardinality_exceeded"
+
+// CardinalityGuard returns a Middleware which tracks the distinct values of attrs with any of
+// the given keys.  Once limit distinct values have been seen for a key, new values are replaced
+// with a placeholder containing a short hash of the value, like "overflow-1a2b3c4d", and an attr
+// with the key CardinalityKey is added next to them, to the record, or to the attrs passed to
+// WithAttrs.  Values seen before the limit was reached are passed unchanged.  Keys are matched
+// at any depth inside groups.  Values are compared as strings.
+func CardinalityGuard(limit int, keys ...string) Middleware {
+	var mu sync.Mutex
+	seen := make(map[string]map[string]struct{}, len(keys))
+	for _, k := range keys {
+		seen[k] = map[string]struct{}{}
+	}
+	// guard replaces overflowing values, and returns the markers for the keys it replaced
+	guard := func(prefix string, attrs []slog.Attr) ([]slog.Attr, []slog.Attr) {
+		mu.Lock()
+		exceeded := guardAttrs(attrs, seen, limit, prefix, nil)
+		mu.Unlock()
+		markers := make([]slog.Attr, len(exceeded))
+		for i, k := range exceeded {
+			markers[i] = slog.String(CardinalityKey, k)
+		}
+		return attrs, markers
+	}
+	return func(next slog.Handler) slog.Handler {
+		return &cardinalityHandler{
+			middlewareHandler: middlewareHandler{next: next},
+			guard:             guard,
+		}
+	}
+}
+
+// cardinalityHandler is the handler of CardinalityGuard.  It tracks the groups opened with
+// WithGroup, so its markers have the full keys of the replaced attrs.
+type cardinalityHandler struct {
+	middlewareHandler
+	guard  func(prefix string, attrs []slog.Attr) ([]slog.Attr, []slog.Attr)
+	prefix string
+}
+
+func (h *cardinalityHandler) Handle(ctx context.Context, r slog.Record) error {
+	var markers []slog.Attr
+	r = mapRecordAttrs(r, func(attrs []slog.Attr) []slog.Attr {
+		attrs, markers = h.guard(h.prefix, attrs)
+		return attrs
+	})
+	r.AddAttrs(markers...)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *cardinalityHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	attrs, markers := h.guard(h.prefix, attrs)
+	h2 := *h
+	h2.next = h.next.WithAttrs(append(attrs, markers...))
+	return &h2
+}
+
+func (h *cardinalityHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.prefix = internal.JoinKey(h.prefix, name)
+	return &h2
+}
+
+// guardAttrs replaces the values of attrs in seen which aren't among the first limit
+// distinct values, in place, recursing into groups, and appends their full keys, joined
+// to prefix, to exceeded.  Groups are copied rather than modified.
+func guardAttrs(attrs []slog.Attr, seen map[string]map[string]struct{}, limit int, prefix string, exceeded []string) []string {
+	for i, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			group := slices.Clone(a.Value.Group())
+			groupPrefix := prefix
+			if a.Key != "" {
+				groupPrefix = internal.JoinKey(prefix, a.Key)
+			}
+			exceeded = guardAttrs(group, seen, limit, groupPrefix, exceeded)
+			a.Value = slog.GroupValue(group...)
+		} else if values, ok := seen[a.Key]; ok {
+			v := a.Value.String()
+			if _, ok := values[v]; !ok {
+				if len(values) < limit {
+					values[v] = struct{}{}
+				} else {
+					h := fnv.New32a()
+					_, _ = h.Write([]byte(v))
+					a.Value = slog.StringValue(fmt.Sprintf("overflow-%08x", h.Sum32()))
+					if k := internal.JoinKey(prefix, a.Key); !slices.Contains(exceeded, k) {
+						exceeded = append(exceeded, k)
+					}
+				}
+			}
+		}
+		attrs[i] = a
+	}
+	return exceeded
+}
+
+// Enrich returns a Middleware which adds the attrs returned by fn to each record.  fn is
+// passed the context given to Handle, so it can extract request-scoped values like
+// trace IDs.
+func Enrich(fn func(ctx context.Context) []slog.Attr) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+				if attrs := fn(ctx); len(attrs) > 0 {
+					r = r.Clone()
+					r.AddAttrs(attrs...)
+				}
+				return next.Handle(ctx, r)
+			},
+		}
+	}
+}
+
+// middlewareHandler is a generic slog.Handler wrapper.  handle is called for each record
+// and must pass it on to next.  If mapAttrs is set, it transforms attrs passed to WithAttrs.
+type middlewareHandler struct {
+	next     slog.Handler
+	handle   func(ctx context.Context, r slog.Record, next slog.Handler) error
+	mapAttrs func([]slog.Attr) []slog.Attr
+}
+
+func (h *middlewareHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *middlewareHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handle(ctx, r, h.next)
+}
+
+func (h *middlewareHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.mapAttrs != nil {
+		attrs = h.mapAttrs(attrs)
+	}
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	return &h2
+}
+
+func (h *middlewareHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	return &h2
+}
+
+// recordDropped passes the count of a dropped record on to the next handler.
+func (h *middlewareHandler) recordDropped() {
+	_ = dropped(h.next)
+}
+
+// Flush flushes the next handler, if it has a Flush method.
+func (h *middlewareHandler) Flush() error {
+	if f, ok := h.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// mapRecordAttrs returns a copy of r with its attrs transformed by fn.
+func mapRecordAttrs(r slog.Record, fn func([]slog.Attr) []slog.Attr) slog.Record {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(fn(attrs)...)
+	return r2
+}
+
+// redactAttrs replaces the values of attrs with the given keys, recursing into groups.
+// attrs is not modified.
+func redactAttrs(attrs []slog.Attr, keys []string, replace func(slog.Value) slog.Value) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		switch {
+		case slices.Contains(keys, a.Key):
+			a.Value = replace(a.Value)
+		case a.Value.Kind() == slog.KindGroup:
+			a.Value = slog.GroupValue(redactAttrs(a.Value.Group(), keys, replace)...)
+		case a.Value.Kind() == slog.KindLogValuer:
+			if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+				a.Value = slog.GroupValue(redactAttrs(v.Group(), keys, replace)...)
+			}
+		}
+		out[i] = a
+	}
+	return out
+}
@@ -0,0 +1,250 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestChain(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next slog.Handler) slog.Handler {
+			return &middlewareHandler{
+				next: next,
+				handle: func(ctx context.Context, r slog.Record, next slog.Handler) error {
+					order = append(order, name)
+					return next.Handle(ctx, r)
+				},
+			}
+		}
+	}
+
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}), mw("a"), mw("b"))
+	slog.New(h).Info("hi")
+	AssertEqual(t, "a,b", order[0]+","+order[1])
+	AssertEqual(t, "INF hi\n", buf.String())
+}
+
+func TestRedact(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}), Redact("password", "token"))
+	l := slog.New(h).With("token", "abc").WithGroup("req")
+	l.Info("login", "user", "bob", "password", "hunter2", slog.Group("auth", "token", "xyz"))
+	AssertEqual(t, "INF login token=[REDACTED] req.user=bob req.password=[REDACTED] req.auth.token=[REDACTED]\n", buf.String())
+}
+
+func TestPseudonymize(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a"}), Pseudonymize([]byte("secret"), "user"))
+	l := slog.New(h)
+	l.Info("a", "user", "bob@example.com", "id", 1)
+	l.Info("b", slog.Group("req", "user", "bob@example.com"))
+	l.With("user", "alice@example.com").Info("c")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	AssertEqual(t, 3, len(lines))
+	_, hash, _ := strings.Cut(lines[0], "user=")
+	hash, _, _ = strings.Cut(hash, " ")
+	AssertEqual(t, 12, len(hash))
+	AssertEqual(t, "a user="+hash+" id=1", lines[0])
+	AssertEqual(t, "b req.user="+hash, lines[1])
+	AssertEqual(t, false, strings.Contains(lines[2], "alice") || strings.Contains(lines[2], hash))
+
+	// a different secret gives different hashes
+	buf.Reset()
+	slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%a"}), Pseudonymize([]byte("other"), "user"))).Info("", "user", "bob@example.com")
+	AssertEqual(t, false, strings.Contains(buf.String(), hash))
+}
+
+func TestSample(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Sample(3)))
+	l2 := l.With("a", 1)
+	for i := 0; i < 3; i++ {
+		l.Info("a")
+		l2.Info("b")
+	}
+	AssertEqual(t, "a\nb\n", buf.String())
+}
+
+func TestSampleDebug(t *testing.T) {
+	rolls := []int{1, 0, 2, 0}
+	defer func(orig func(int) int) { sampleRand = orig }(sampleRand)
+	sampleRand = func(n int) int {
+		AssertEqual(t, 3, n)
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", Level: slog.LevelDebug}), SampleDebug(3)))
+	for i := 0; i < 4; i++ {
+		l.Debug("debug", "i", i)
+	}
+	l.Info("info")
+	l.Warn("warn")
+	AssertEqual(t, "DBG debug i=1 sampled=1/3\nDBG debug i=3 sampled=1/3\nINF info\nWRN warn\n", buf.String())
+}
+
+func TestSampleDebug_One(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", Level: slog.LevelDebug}), SampleDebug(1)))
+	l.Debug("debug")
+	AssertEqual(t, "DBG debug\n", buf.String())
+}
+
+func TestThrottle(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}), Throttle(2, 3, time.Second))
+	start := time.Now()
+	handle := func(at time.Duration, level slog.Level, msg string) {
+		AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(start.Add(at), level, msg, 0)))
+	}
+	for i := 0; i < 8; i++ {
+		handle(0, slog.LevelInfo, "a")
+	}
+	handle(0, slog.LevelWarn, "a")
+	handle(0, slog.LevelInfo, "b")
+	// counts reset after the tick
+	handle(time.Second, slog.LevelInfo, "a")
+	AssertEqual(t, "INF a\nINF a\nINF a occurrence=5\nINF a occurrence=8\nWRN a\nINF b\nINF a\n", buf.String())
+}
+
+func TestThrottle_FirstOnly(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Throttle(1, 0, 0)))
+	for i := 0; i < 5; i++ {
+		l.Info("a")
+	}
+	AssertEqual(t, "a\n", buf.String())
+}
+
+func TestThrottle_ZeroTime(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Throttle(1, 0, 10*time.Millisecond))
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	time.Sleep(20 * time.Millisecond)
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	time.Sleep(20 * time.Millisecond)
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	AssertEqual(t, "a\na\na\n", buf.String())
+}
+
+func TestOnce(t *testing.T) {
+	t.Cleanup(func() { onceSeen.ids, onceSeen.order = nil, nil })
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"})
+	l := slog.New(Chain(h, Once()))
+	other := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}), Once()))
+	for i := 0; i < 3; i++ {
+		l.Warn("Do is deprecated", OnceKey, true, "i", i)
+		l.Info("Do is deprecated", OnceKey, true)
+		l.Warn("deprecated", OnceKey, "do", "i", i)
+		l.Warn("renamed", OnceKey, "do")
+		l.Info("always", OnceKey, false)
+		other.Warn("Do is deprecated", OnceKey, true, "other", true)
+	}
+	l.Info("unmarked")
+	AssertEqual(t, "WRN Do is deprecated i=0\n"+
+		"INF Do is deprecated\n"+
+		"WRN deprecated i=0\n"+
+		"INF always\n"+
+		"INF always\n"+
+		"INF always\n"+
+		"INF unmarked\n", buf.String())
+	AssertEqual(t, int64(9), h.Metrics().Dropped)
+}
+
+func TestOnce_Limit(t *testing.T) {
+	t.Cleanup(func() { onceSeen.ids, onceSeen.order = nil, nil })
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Once()))
+	for i := 0; i < onceLimit; i++ {
+		l.Info(strconv.Itoa(i), OnceKey, true)
+	}
+	AssertEqual(t, onceLimit, len(onceSeen.ids))
+	buf.Reset()
+	l.Info("1", OnceKey, true)
+	AssertEqual(t, "", buf.String())
+
+	// the oldest is forgotten
+	l.Info("new", OnceKey, true)
+	AssertEqual(t, onceLimit, len(onceSeen.ids))
+	l.Info("0", OnceKey, true)
+	AssertEqual(t, "new\n0\n", buf.String())
+}
+
+func TestCardinalityGuard(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := slog.New(Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a"}), CardinalityGuard(2, "user")))
+	l.Info("a", "user", "bob")
+	l.Info("b", "user", "alice")
+	l.Info("c", "user", "carol", "other", "x")
+	l.Info("d", "user", "bob")
+	l.WithGroup("req").Info("e", "user", "dave")
+	l.Info("f", slog.Group("http", "user", "frank"))
+	// values replaced in WithAttrs are marked in the handler's attrs
+	l.With("user", "erin").Info("g")
+	l.WithGroup("req").With(slog.Group("http", "user", "gina")).Info("h")
+	AssertEqual(t, "a user=bob\n"+
+		"b user=alice\n"+
+		"c user=overflow-67088f12 other=x cardinality_exceeded=user\n"+
+		"d user=bob\n"+
+		"e req.user=overflow-d06cc5df req.cardinality_exceeded=req.user\n"+
+		"f http.user=overflow-f40ce5c3 cardinality_exceeded=http.user\n"+
+		"g user=overflow-36ad59f9 cardinality_exceeded=user\n"+
+		"h req.http.user=overflow-855f9558 req.cardinality_exceeded=req.http.user\n", buf.String())
+}
+
+func TestDedup(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Dedup(time.Second))
+	start := time.Now()
+	for _, r := range []struct {
+		msg string
+		at  time.Duration
+	}{
+		{"a", 0},
+		{"a", 100 * time.Millisecond},
+		{"b", 200 * time.Millisecond},
+		{"b", 2 * time.Second},
+		{"a", 2 * time.Second},
+	} {
+		AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(start.Add(r.at), slog.LevelInfo, r.msg, 0)))
+	}
+	AssertEqual(t, "a\nb\nb\na\n", buf.String())
+}
+
+func TestDedup_ZeroTime(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}), Dedup(10*time.Millisecond))
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	time.Sleep(20 * time.Millisecond)
+	AssertNoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	AssertEqual(t, "a\na\n", buf.String())
+}
+
+func TestEnrich(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}), Enrich(func(ctx context.Context) []slog.Attr {
+		if id, ok := ctx.Value(ctxKey{}).(string); ok {
+			return []slog.Attr{slog.String("request_id", id)}
+		}
+		return nil
+	}))
+	l := slog.New(h)
+	l.InfoContext(context.WithValue(context.Background(), ctxKey{}, "42"), "handled", "status", 200)
+	l.Info("plain")
+	AssertEqual(t, "INF handled status=200 request_id=42\nINF plain\n", buf.String())
+}
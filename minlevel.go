@@ -0,0 +1,30 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+)
+
+type minLevelKey struct{}
+
+// WithMinLevel returns a copy of ctx which overrides the minimum level of Handlers, for
+// records logged with the context.  It can lower the level, e.g. to log debug records for
+// a single request, triggered by a request header, while the handler stays at info:
+//
+//	ctx = console.WithMinLevel(ctx, slog.LevelDebug)
+//	logger.DebugContext(ctx, "request headers", "headers", r.Header)
+//
+// or raise it, to quiet a noisy operation.  Only the *Context logging methods pass the
+// context to the handler.
+func WithMinLevel(ctx context.Context, level slog.Level) context.Context {
+	return context.WithValue(ctx, minLevelKey{}, level)
+}
+
+// MinLevelFromContext returns the minimum level set with WithMinLevel, if any.
+func MinLevelFromContext(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	l, ok := ctx.Value(minLevelKey{}).(slog.Level)
+	return l, ok
+}
@@ -0,0 +1,41 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWithMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m"}))
+	ctx := context.Background()
+
+	logger.DebugContext(ctx, "dropped")
+	logger.DebugContext(WithMinLevel(ctx, slog.LevelDebug), "debug")
+	logger.InfoContext(WithMinLevel(ctx, slog.LevelWarn), "quieted")
+	logger.WarnContext(WithMinLevel(ctx, slog.LevelWarn), "warn")
+	logger.With("a", 1).DebugContext(WithMinLevel(ctx, slog.LevelDebug), "derived")
+
+	AssertEqual(t, "DBG debug\nWRN warn\nDBG derived\n", buf.String())
+}
+
+func TestWithMinLevel_Handle(t *testing.T) {
+	// records passed directly to Handle are filtered by the context's level too
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m"})
+	ctx := WithMinLevel(context.Background(), slog.LevelWarn)
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "quieted", 0)))
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelError, "error", 0)))
+	AssertEqual(t, "ERR error\n", buf.String())
+}
+
+func TestMinLevelFromContext(t *testing.T) {
+	_, ok := MinLevelFromContext(context.Background())
+	AssertEqual(t, false, ok)
+	l, ok := MinLevelFromContext(WithMinLevel(context.Background(), slog.LevelDebug))
+	AssertEqual(t, true, ok)
+	AssertEqual(t, slog.LevelDebug, l)
+}
@@ -0,0 +1,58 @@
+package console
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+)
+
+// writeNetValue writes IP addresses, networks, and URLs.  Addresses are written
+// in their canonical form, with IPv4-mapped IPv6 addresses written as IPv4, without
+// allocating.  URLs have their passwords masked if ScrubURLCredentials is set.
+func (e *encoder) writeNetValue(buf *buffer, v any) {
+	switch v := v.(type) {
+	case netip.Addr:
+		if !v.IsValid() {
+			buf.AppendString(v.String())
+			return
+		}
+		*buf = v.Unmap().AppendTo(*buf)
+	case netip.Prefix:
+		*buf = v.AppendTo(*buf)
+	case netip.AddrPort:
+		*buf = v.AppendTo(*buf)
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(v)
+		if !ok {
+			// nil, or not a valid length
+			buf.AppendString(v.String())
+			return
+		}
+		*buf = addr.Unmap().AppendTo(*buf)
+	case *net.IPNet:
+		if v == nil {
+			buf.AppendString("<nil>")
+			return
+		}
+		addr, ok := netip.AddrFromSlice(v.IP)
+		ones, bits := v.Mask.Size()
+		if !ok || bits == 0 {
+			// non-canonical mask
+			buf.AppendString(v.String())
+			return
+		}
+		if addr.Is4In6() && bits == 8*net.IPv4len {
+			addr = addr.Unmap()
+		}
+		*buf = netip.PrefixFrom(addr, ones).AppendTo(*buf)
+	case *url.URL:
+		switch {
+		case v == nil:
+			buf.AppendString("<nil>")
+		case e.h.opts.ScrubURLCredentials:
+			buf.AppendString(v.Redacted())
+		default:
+			buf.AppendString(v.String())
+		}
+	}
+}
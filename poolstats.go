@@ -0,0 +1,32 @@
+package console
+
+import "sync/atomic"
+
+var poolStats struct {
+	gets, news, grows, discards atomic.Int64
+}
+
+// PoolStats are statistics about the pool of encoders used to render records, for
+// tuning memory use.  They're counted across all handlers since the program started.
+type PoolStats struct {
+	// Gets is the number of encoders taken from the pool, about one per record.
+	Gets int64
+	// News is the number of encoders allocated because the pool was empty.
+	News int64
+	// Grows is the number of times a buffer grew to fit a record.  It settles once the
+	// pooled buffers have grown to fit typical records.
+	Grows int64
+	// Discards is the number of buffers which grew larger than 16KiB, and were dropped
+	// instead of returned to the pool.
+	Discards int64
+}
+
+// EncoderPoolStats returns the encoder pool statistics.
+func EncoderPoolStats() PoolStats {
+	return PoolStats{
+		Gets:     poolStats.gets.Load(),
+		News:     poolStats.news.Load(),
+		Grows:    poolStats.grows.Load(),
+		Discards: poolStats.discards.Load(),
+	}
+}
@@ -0,0 +1,20 @@
+package console
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestEncoderPoolStats(t *testing.T) {
+	before := EncoderPoolStats()
+	l := slog.New(NewHandler(io.Discard, nil))
+	l.Info("small")
+	l.Info("large", "value", strings.Repeat("line\n", 10000))
+	after := EncoderPoolStats()
+
+	AssertEqual(t, true, after.Gets >= before.Gets+2)
+	AssertEqual(t, true, after.Grows > before.Grows)
+	AssertEqual(t, true, after.Discards > before.Discards)
+}
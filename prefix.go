@@ -0,0 +1,78 @@
+package console
+
+import "errors"
+
+// prefixColors are the colors prefixes are styled with, chosen by the hash of the prefix.
+var prefixColors = []ANSIMod{
+	ToANSICode(Cyan),
+	ToANSICode(Green),
+	ToANSICode(Yellow),
+	ToANSICode(Blue),
+	ToANSICode(Magenta),
+	ToANSICode(BrightCyan),
+	ToANSICode(BrightGreen),
+	ToANSICode(BrightYellow),
+	ToANSICode(BrightBlue),
+	ToANSICode(BrightMagenta),
+}
+
+// prefixColor returns the color for the prefix.  The same prefix always gets the same color.
+func prefixColor(prefix string) ANSIMod {
+	// FNV-1a, inlined to avoid allocating on each record
+	h := uint32(2166136261)
+	for i := 0; i < len(prefix); i++ {
+		h ^= uint32(prefix[i])
+		h *= 16777619
+	}
+	return prefixColors[h%uint32(len(prefixColors))]
+}
+
+// WithPrefix returns a handler which prints "[prefix] " at the start of each line, like
+// HandlerOptions.Prefix.
+func (h *Handler) WithPrefix(prefix string) *Handler {
+	h = h.current()
+	h2 := *h
+	h2.derived = new(derivedHandlers)
+	h2.opts.Prefix = prefix
+	return &h2
+}
+
+// ErrNoTenant is returned by Handle when HandlerOptions.RequireTenant is set, and the
+// handler wasn't derived with WithTenant.
+var ErrNoTenant = errors.New("console: record has no tenant")
+
+// WithTenant returns a handler for the tenant's output, which prints "[tenant] " at the
+// start of each line, before any prefix, in a color chosen by hashing the tenant ID, so
+// each tenant's lines can be told apart.  Handlers derived from it keep the tenant.  If
+// HandlerOptions.RequireTenant is set, only handlers derived with WithTenant log records.
+func (h *Handler) WithTenant(id string) *Handler {
+	h = h.current()
+	h2 := *h
+	h2.derived = new(derivedHandlers)
+	h2.tenant = id
+	return &h2
+}
+
+// Tenant returns the tenant set with WithTenant, if any.
+func (h *Handler) Tenant() string {
+	return h.tenant
+}
+
+func (e *encoder) writePrefix() {
+	if e.h.tenant != "" {
+		e.writeBracketed(e.h.tenant)
+	}
+	if e.h.opts.Prefix != "" {
+		e.writeBracketed(e.h.opts.Prefix)
+	}
+}
+
+// writeBracketed writes "[s] ", in the color for s.
+func (e *encoder) writeBracketed(s string) {
+	e.withColor(&e.buf, prefixColor(s), func() {
+		e.buf.AppendByte('[')
+		e.buf.AppendString(s)
+		e.buf.AppendByte(']')
+	})
+	e.buf.AppendByte(' ')
+}
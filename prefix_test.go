@@ -0,0 +1,111 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHandler_Prefix(t *testing.T) {
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", Prefix: "api"},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.String("a", "1")},
+		want:  "[api] INF msg a=1\n",
+	}.run(t)
+
+	theme := NewDefaultTheme()
+	handlerTest{
+		opts: HandlerOptions{Theme: theme, HeaderFormat: "%m", Prefix: "api"},
+		msg:  "msg",
+		want: styled("[api]", prefixColor("api")) + " " + styled("msg", theme.Message) + "\n",
+	}.run(t)
+}
+
+func TestHandler_WithPrefix(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("a", "1")}).(*Handler).WithPrefix("worker")
+		},
+		msg:  "msg",
+		want: "[worker] INF msg a=1\n",
+	}.run(t)
+}
+
+func TestHandler_GroupOnMessage(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", GroupOnMessage: true},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithGroup("server").WithGroup("http")
+		},
+		msg:   "listening",
+		attrs: []slog.Attr{slog.Int("port", 80)},
+		want:  "INF server.http: listening server.http.port=80\n",
+	}.run(t)
+
+	// no group, or no message
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", GroupOnMessage: true},
+		msg:  "listening",
+		want: "INF listening\n",
+	}.run(t)
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", GroupOnMessage: true},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithGroup("db")
+		},
+		attrs: []slog.Attr{slog.Int("n", 1)},
+		want:  "INF db: db.n=1\n",
+	}.run(t)
+
+	theme := NewDefaultTheme()
+	handlerTest{
+		opts: HandlerOptions{Theme: theme, HeaderFormat: "%m", GroupOnMessage: true},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithGroup("db")
+		},
+		msg:  "connected",
+		want: styled("db:", theme.Header) + " " + styled("connected", theme.Message) + "\n",
+	}.run(t)
+}
+
+func TestPrefixColor(t *testing.T) {
+	AssertEqual(t, prefixColor("api"), prefixColor("api"))
+	colors := map[ANSIMod]bool{}
+	for _, p := range []string{"api", "worker", "db", "web", "queue", "cron"} {
+		colors[prefixColor(p)] = true
+	}
+	AssertEqual(t, true, len(colors) > 1)
+}
+
+func TestHandler_WithTenant(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", RequireTenant: true})
+	ctx := context.Background()
+
+	// records without a tenant are refused
+	AssertEqual(t, false, h.Enabled(ctx, slog.LevelInfo))
+	AssertEqual(t, ErrNoTenant, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	AssertEqual(t, "", buf.String())
+
+	acme := h.WithTenant("acme")
+	AssertEqual(t, "acme", acme.Tenant())
+	AssertEqual(t, true, acme.Enabled(ctx, slog.LevelInfo))
+	l := slog.New(acme.WithPrefix("api")).With("a", 1)
+	l.Info("msg")
+	AssertEqual(t, "[acme] [api] INF msg a=1\n", buf.String())
+
+	// the tenant gets its own color
+	theme := NewDefaultTheme()
+	handlerTest{
+		opts: HandlerOptions{Theme: theme, HeaderFormat: "%m"},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.(*Handler).WithTenant("acme")
+		},
+		msg:  "msg",
+		want: styled("[acme]", prefixColor("acme")) + " " + styled("msg", theme.Message) + "\n",
+	}.run(t)
+}
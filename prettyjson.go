@@ -0,0 +1,59 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+)
+
+// isPrettyJSON reports whether the key is one of the PrettyJSONKeys.
+func (e *encoder) isPrettyJSON(groupPrefix, key string) bool {
+	for _, k := range e.h.opts.PrettyJSONKeys {
+		if matchKey(k, groupPrefix, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// prettyJSON returns v as indented JSON, and whether it could be.  Strings and byte slices
+// which hold JSON are indented; other values are marshaled, with groups as objects.
+func prettyJSON(v slog.Value) (string, bool) {
+	var raw []byte
+	switch v.Kind() {
+	case slog.KindString:
+		raw = []byte(v.String())
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case json.RawMessage:
+			raw = a
+		case []byte:
+			raw = a
+		}
+	}
+	if raw != nil {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return "", false
+		}
+		return buf.String(), true
+	}
+	b, err := json.MarshalIndent(jsonValue(v), "", "  ")
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// jsonValue returns v as a value for json.Marshal, with groups as maps.
+func jsonValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	m := make(map[string]any, len(v.Group()))
+	for _, a := range v.Group() {
+		m[a.Key] = jsonValue(a.Value)
+	}
+	return m
+}
@@ -0,0 +1,67 @@
+package console
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_PrettyJSONKeys(t *testing.T) {
+	opts := HandlerOptions{NoColor: true, HeaderFormat: "%m %a", PrettyJSONKeys: []string{"body", "req.body"}}
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{
+			name: "string",
+			attr: slog.String("body", `{"a":1,"b":[1,2]}`),
+			want: "msg\n=== body ===\n{\n  \"a\": 1,\n  \"b\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			name: "raw message",
+			attr: slog.Any("body", json.RawMessage(`{"a":1}`)),
+			want: "msg\n=== body ===\n{\n  \"a\": 1\n}\n",
+		},
+		{
+			name: "struct",
+			attr: slog.Any("body", struct{ Name string }{"bob"}),
+			want: "msg\n=== body ===\n{\n  \"Name\": \"bob\"\n}\n",
+		},
+		{
+			name: "group",
+			attr: slog.Group("body", slog.Int("b", 2), slog.Int("a", 1)),
+			want: "msg\n=== body ===\n{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+		},
+		{
+			name: "in group",
+			attr: slog.Group("req", slog.String("body", `[1]`)),
+			want: "msg\n=== req.body ===\n[\n  1\n]\n",
+		},
+		{
+			name: "scalar stays inline",
+			attr: slog.Int("body", 5),
+			want: "msg body=5\n",
+		},
+		{
+			name: "not json",
+			attr: slog.String("body", "hello"),
+			want: "msg body=hello\n",
+		},
+		{
+			name: "other keys",
+			attr: slog.String("other", `{"a":1}`),
+			want: "msg other={\"a\":1}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerTest{
+				opts:  opts,
+				msg:   "msg",
+				attrs: []slog.Attr{tt.attr},
+				want:  tt.want,
+			}.run(t)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package console
+
+import "log/slog"
+
+// RawValue is text which is written verbatim into the multiline section at the end of the
+// log line, on its own line, without the key, quoting, or styling.  Create attrs holding raw
+// values with [Raw].
+type RawValue string
+
+// Raw returns an attr which the handler writes verbatim into the multiline section at the
+// end of the log line.  Use it for preformatted blocks, like stack dumps, SQL, or the
+// output of child processes, which should keep their layout.  The key is not printed, but
+// may still be used to select the attr in ReplaceAttr.
+//
+//	logger.Error("build failed", console.Raw("output", compilerOutput))
+func Raw(key, text string) slog.Attr {
+	return slog.Any(key, RawValue(text))
+}
+
+// String implements fmt.Stringer.
+func (r RawValue) String() string {
+	return string(r)
+}
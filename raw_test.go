@@ -0,0 +1,59 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/ansel1/console-slog/internal"
+)
+
+func TestHandler_Raw(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "raw",
+			attrs: []slog.Attr{slog.String("foo", "bar"), Raw("output", "  line 1\n\tline \"2\"")},
+			want:  "INF failed foo=bar\n  line 1\n\tline \"2\"\n",
+		},
+		{
+			name: "raw with multiline attrs",
+			attrs: []slog.Attr{
+				slog.String("stack", "a\nb"),
+				Raw("output", "raw"),
+			},
+			want: "INF failed\n=== stack ===\na\nb\nraw\n",
+		},
+		{
+			name:  "colors are not applied",
+			opts:  HandlerOptions{HeaderFormat: "%a"},
+			attrs: []slog.Attr{Raw("output", "raw")},
+			want:  "\nraw\n",
+		},
+		{
+			name:  "raw in group",
+			attrs: []slog.Attr{slog.Group("g", Raw("output", "raw"))},
+			want:  "INF failed\nraw\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "failed"
+		if tt.opts.HeaderFormat == "" {
+			tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}
+		}
+		t.Run(tt.name, tt.run)
+	}
+
+	t.Run("old multiline", func(t *testing.T) {
+		oldValue := internal.FeatureFlagNewMultilineAttrs
+		internal.FeatureFlagNewMultilineAttrs = false
+		t.Cleanup(func() {
+			internal.FeatureFlagNewMultilineAttrs = oldValue
+		})
+		handlerTest{
+			msg:   "failed",
+			opts:  HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"},
+			attrs: []slog.Attr{slog.String("foo", "bar"), Raw("output", "raw")},
+			want:  "INF failed foo=bar\nraw\n",
+		}.run(t)
+	})
+}
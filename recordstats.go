@@ -0,0 +1,111 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram counts values in power of two buckets, see RecordStats.
+type Histogram struct {
+	// Buckets counts the values by their bit length: Buckets[0] counts zeros, and
+	// Buckets[i] counts the values from 2^(i-1) up to, but not including, 2^i.
+	Buckets [64]int64
+	// Count, Sum and Max are the number of values, their total, and the largest.
+	Count, Sum, Max int64
+}
+
+// Mean returns the mean of the values, or 0 if there are none.
+func (h Histogram) Mean() int64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / h.Count
+}
+
+// histogram is a Histogram which is updated atomically.
+type histogram struct {
+	buckets         [64]atomic.Int64
+	count, sum, max atomic.Int64
+}
+
+func (h *histogram) observe(v int64) {
+	v = max(v, 0)
+	h.buckets[min(bits.Len64(uint64(v)), len(h.buckets)-1)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(v)
+	for m := h.max.Load(); v > m && !h.max.CompareAndSwap(m, v); m = h.max.Load() {
+	}
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{Count: h.count.Load(), Sum: h.sum.Load(), Max: h.max.Load()}
+	for i := range h.buckets {
+		s.Buckets[i] = h.buckets[i].Load()
+	}
+	return s
+}
+
+// RecordStats are histograms of how long records took to render, and how large they
+// were, for finding the log statements which stall hot paths.  See
+// HandlerOptions.RecordStats.
+type RecordStats struct {
+	// EncodeTime is the time each record took to render, in nanoseconds.
+	EncodeTime Histogram
+	// Size is the size of each rendered record, in bytes.
+	Size Histogram
+}
+
+// recordStats are the histograms behind Handler.RecordStats.
+type recordStats struct {
+	encodeTime, size histogram
+}
+
+// RecordStats returns the handler's record histograms.  They're only collected if
+// HandlerOptions.RecordStats is set.  Like Metrics, they're counted across the handler
+// and all the handlers derived from it, or from the same parent.
+func (h *Handler) RecordStats() RecordStats {
+	s := &h.shared.recordStats
+	return RecordStats{
+		EncodeTime: s.encodeTime.snapshot(),
+		Size:       s.size.snapshot(),
+	}
+}
+
+// tracesRecords reports whether records need to be timed and measured.
+func (h *Handler) tracesRecords() bool {
+	return h.opts.RecordStats || h.opts.SlowRecordThreshold > 0 || h.opts.LargeRecordThreshold > 0
+}
+
+// traceRecord records the time the record took to render, and its size, and writes a
+// warning after it if it exceeded SlowRecordThreshold or LargeRecordThreshold.
+func (h *Handler) traceRecord(ctx context.Context, rec slog.Record, d time.Duration, size int) error {
+	if h.opts.RecordStats {
+		h.shared.recordStats.encodeTime.observe(int64(d))
+		h.shared.recordStats.size.observe(int64(size))
+	}
+	var msg string
+	switch {
+	case h.opts.SlowRecordThreshold > 0 && d > h.opts.SlowRecordThreshold:
+		msg = "console: slow log record"
+	case h.opts.LargeRecordThreshold > 0 && size > h.opts.LargeRecordThreshold:
+		msg = "console: large log record"
+	default:
+		return nil
+	}
+	warn := slog.NewRecord(rec.Time, slog.LevelWarn, msg, rec.PC)
+	warn.AddAttrs(
+		slog.String("record", rec.Message),
+		slog.Duration("encode", d),
+		slog.Int("bytes", size),
+	)
+	if rec.PC != 0 && !h.opts.AddSource {
+		frame, _ := runtime.CallersFrames([]uintptr{rec.PC}).Next()
+		warn.AddAttrs(slog.Any(slog.SourceKey, &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}))
+	}
+	// the warning is about the record, so it doesn't carry the logger's groups or attrs
+	return h.write(ctx, h.root().render(warn))
+}
@@ -0,0 +1,93 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, v := range []int64{0, 1, 2, 3, 4, 1000} {
+		h.observe(v)
+	}
+	s := h.snapshot()
+	AssertEqual(t, int64(6), s.Count)
+	AssertEqual(t, int64(1010), s.Sum)
+	AssertEqual(t, int64(1000), s.Max)
+	AssertEqual(t, int64(168), s.Mean())
+	AssertEqual(t, [64]int64{1, 1, 2, 1, 0, 0, 0, 0, 0, 0, 1}, s.Buckets)
+	AssertEqual(t, int64(0), Histogram{}.Mean())
+}
+
+func TestHandler_RecordStats(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", RecordStats: true})
+	l := slog.New(h)
+	l.Info("hi")
+	l.With("a", 1).Info("hello")
+	s := h.RecordStats()
+	AssertEqual(t, int64(2), s.Size.Count)
+	AssertEqual(t, int64(len("hi")+len("hello a=1")), s.Size.Sum)
+	AssertEqual(t, int64(2), s.EncodeTime.Count)
+	AssertEqual(t, "hi\nhello a=1\n", buf.String())
+
+	// not collected by default
+	h = NewHandler(&buf, nil)
+	slog.New(h).Info("hi")
+	AssertEqual(t, RecordStats{}, h.RecordStats())
+}
+
+func TestHandler_LargeRecordThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", LargeRecordThreshold: 20})
+	l := slog.New(h)
+	l.Info("small")
+	AssertEqual(t, "INF small\n", buf.String())
+
+	buf.Reset()
+	l.Info("big", "data", strings.Repeat("x", 20))
+	lines := strings.Split(buf.String(), "\n")
+	AssertEqual(t, "INF big data="+strings.Repeat("x", 20), lines[0])
+	if !strings.HasPrefix(lines[1], "WRN console: large log record record=big encode=") ||
+		!strings.Contains(lines[1], " bytes=33 source=") ||
+		!strings.Contains(lines[1], "recordstats_test.go:") {
+		t.Errorf("unexpected warning: %q", lines[1])
+	}
+}
+
+func TestHandler_SlowRecordThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", SlowRecordThreshold: time.Millisecond})
+	slog.New(h).Info("slow", "v", slowValuer{})
+	lines := strings.Split(buf.String(), "\n")
+	AssertEqual(t, "INF slow v=done", lines[0])
+	if !strings.HasPrefix(lines[1], "WRN console: slow log record record=slow encode=") {
+		t.Errorf("unexpected warning: %q", lines[1])
+	}
+}
+
+func TestHandler_LargeRecordThresholdGroups(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", LargeRecordThreshold: 20, AddSource: true})
+	slog.New(h).With("a", 1).WithGroup("g").Info("big", "data", strings.Repeat("x", 20))
+	lines := strings.Split(buf.String(), "\n")
+	AssertEqual(t, 3, len(lines))
+	warn := lines[1]
+	if !strings.HasPrefix(warn, "WRN console: large log record source=") ||
+		!strings.Contains(warn, " record=big encode=") ||
+		strings.Count(warn, "source=") != 1 ||
+		strings.Contains(warn, "a=1") ||
+		strings.Contains(warn, "g.") {
+		t.Errorf("unexpected warning: %q", warn)
+	}
+}
+
+type slowValuer struct{}
+
+func (slowValuer) LogValue() slog.Value {
+	time.Sleep(5 * time.Millisecond)
+	return slog.StringValue("done")
+}
@@ -0,0 +1,60 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// RecoverAndLog recovers a panic, logs it at error level with the stack trace, flushes the
+// logger's handler, and then panics again with the same value.  It must be deferred
+// directly:
+//
+//	func main() {
+//		logger := slog.New(console.NewHandler(os.Stderr, nil))
+//		defer console.RecoverAndLog(logger)
+//		...
+//	}
+//
+// The record is logged with the message "panic", the panic value in the "panic" attr, and the
+// stack trace in the "stack" attr.  If the handler has a Flush method, like Handler, it's
+// called after the record is logged, so buffered output isn't lost when the program
+// crashes.
+func RecoverAndLog(logger *slog.Logger) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	h := logger.Handler()
+	ctx := context.Background()
+	if h.Enabled(ctx, slog.LevelError) {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "panic", panicPC())
+		r.AddAttrs(slog.Any("panic", v), slog.String("stack", string(debug.Stack())))
+		_ = h.Handle(ctx, r)
+	}
+	if f, ok := h.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	panic(v)
+}
+
+// panicPC returns the pc of the function which panicked, skipping the runtime's frames.
+func panicPC() uintptr {
+	var pcs [16]uintptr
+	// skip runtime.Callers, panicPC and RecoverAndLog
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			return f.PC
+		}
+		if !more {
+			return 0
+		}
+	}
+}
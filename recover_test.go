@@ -0,0 +1,54 @@
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	h := Chain(NewHandler(bw, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %s %a", AddSource: true}), Redact("password"))
+	logger := slog.New(h)
+
+	var repanicked any
+	func() {
+		defer func() { repanicked = recover() }()
+		defer RecoverAndLog(logger)
+		panic("boom")
+	}()
+
+	AssertEqual(t, "boom", repanicked)
+	out := buf.String()
+	if !strings.HasPrefix(out, "ERR panic recover_test.go:") {
+		t.Errorf("expected record with the source of the panic, got %q", out)
+	}
+	if !strings.Contains(out, " panic=boom\n=== stack ===\n") {
+		t.Errorf("expected panic value and stack, got %q", out)
+	}
+	if !strings.Contains(out, "TestRecoverAndLog") {
+		t.Errorf("expected stack to include the test function, got %q", out)
+	}
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true}))
+	func() {
+		defer RecoverAndLog(logger)
+	}()
+	AssertEqual(t, "", buf.String())
+}
+
+func TestHandler_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	h := NewHandler(bw, &HandlerOptions{NoColor: true, HeaderFormat: "%m"})
+	slog.New(h).Info("hello")
+	AssertEqual(t, "", buf.String())
+	AssertNoError(t, h.Flush())
+	AssertEqual(t, "hello\n", buf.String())
+}
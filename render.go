@@ -0,0 +1,37 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+)
+
+// Render renders records to w, as a Handler created with opts would, for tools which
+// collect records first, and print a report afterwards.  Records below opts.Level are
+// skipped.  Output is buffered, and written to w in as few writes as possible.
+func Render(w io.Writer, opts *HandlerOptions, records []slog.Record) error {
+	bw := bufio.NewWriter(w)
+	h := NewHandler(bw, opts)
+	ctx := context.Background()
+	for _, r := range records {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// RenderSorted is like Render, but renders the records in order of their times.  Records
+// with the same time keep their order.  records is not modified.
+func RenderSorted(w io.Writer, opts *HandlerOptions, records []slog.Record) error {
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b slog.Record) int {
+		return a.Time.Compare(b.Time)
+	})
+	return Render(w, opts, sorted)
+}
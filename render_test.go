@@ -0,0 +1,44 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestRender(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rec := func(at time.Duration, level slog.Level, msg string, args ...any) slog.Record {
+		r := slog.NewRecord(start.Add(at), level, msg, 0)
+		r.Add(args...)
+		return r
+	}
+	records := []slog.Record{
+		rec(2*time.Second, slog.LevelInfo, "second", "a", 1),
+		rec(time.Second, slog.LevelWarn, "first"),
+		rec(0, slog.LevelDebug, "debug"),
+		rec(2*time.Second, slog.LevelError, "third"),
+	}
+	opts := &HandlerOptions{NoColor: true, HeaderFormat: "%t %l %m %a", TimeFormat: "15:04:05"}
+
+	var w countingWriter
+	AssertNoError(t, Render(&w, opts, records))
+	AssertEqual(t, "03:04:07 INF second a=1\n03:04:06 WRN first\n03:04:07 ERR third\n", w.String())
+	AssertEqual(t, 1, w.writes)
+
+	w.Reset()
+	AssertNoError(t, RenderSorted(&w, opts, records))
+	AssertEqual(t, "03:04:06 WRN first\n03:04:07 INF second a=1\n03:04:07 ERR third\n", w.String())
+	AssertEqual(t, "second", records[0].Message)
+}
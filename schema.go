@@ -0,0 +1,48 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Schema returns a HandlerOptions.ValidateAttr func which checks the values of known keys
+// have the expected kinds.  Keys are full keys, joined to their groups with ".", like
+// "req.status".  Keys not in the schema aren't checked.
+//
+//	opts.ValidateAttr = console.Schema(map[string]slog.Kind{
+//		"user_id":    slog.KindInt64,
+//		"req.status": slog.KindInt64,
+//	})
+func Schema(kinds map[string]slog.Kind) func(key string, v slog.Value) error {
+	return func(key string, v slog.Value) error {
+		want, ok := kinds[key]
+		if !ok || v.Kind() == want {
+			return nil
+		}
+		return fmt.Errorf("want %s, got %s", want, v.Kind())
+	}
+}
+
+// Violations returns the number of attrs which failed HandlerOptions.ValidateAttr in this
+// handler, and all the handlers derived from it, or from the same parent.
+func (h *Handler) Violations() int {
+	return int(h.shared.violations.Load())
+}
+
+// writeViolation counts an attr which failed ValidateAttr, and annotates the line with
+// its key and the error.
+func (e *encoder) writeViolation(groupPrefix, key string, err error) {
+	e.h.shared.violations.Add(1)
+	e.attrBuf.AppendByte(' ')
+	e.withColor(&e.attrBuf, e.h.opts.Theme.AttrValueError, func() {
+		e.attrBuf.AppendString("![")
+		if groupPrefix != "" {
+			e.attrBuf.AppendString(groupPrefix)
+			e.attrBuf.AppendByte('.')
+		}
+		e.attrBuf.AppendString(key)
+		e.attrBuf.AppendString(": ")
+		e.attrBuf.AppendString(err.Error())
+		e.attrBuf.AppendByte(']')
+	})
+}
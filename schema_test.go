@@ -0,0 +1,52 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_ValidateAttr(t *testing.T) {
+	schema := Schema(map[string]slog.Kind{
+		"user_id":    slog.KindInt64,
+		"req.status": slog.KindInt64,
+	})
+
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%m %a", ValidateAttr: schema},
+		msg:  "hi",
+		attrs: []slog.Attr{
+			slog.String("user_id", "bob"),
+			slog.Group("req", slog.Int("status", 200), slog.String("path", "/")),
+			slog.String("other", "x"),
+		},
+		want: "hi user_id=bob ![user_id: want Int64, got String] req.status=200 req.path=/ other=x\n",
+	}.run(t)
+
+	// header attrs are annotated with the attrs
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%[req.status]h %m %a", ValidateAttr: schema},
+		msg:   "hi",
+		attrs: []slog.Attr{slog.Group("req", slog.String("status", "ok"))},
+		want:  "ok hi ![req.status: want Int64, got String]\n",
+	}.run(t)
+
+	theme := NewDefaultTheme()
+	handlerTest{
+		opts: HandlerOptions{NoColor: false, HeaderFormat: "%a", ValidateAttr: func(key string, v slog.Value) error {
+			return errors.New("bad")
+		}},
+		attrs: []slog.Attr{slog.Int("a", 1)},
+		want:  styled("a=", theme.AttrKey) + "1 " + styled("![a: bad]", theme.AttrValueError) + "\n",
+	}.run(t)
+}
+
+func TestHandler_Violations(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, ValidateAttr: Schema(map[string]slog.Kind{"n": slog.KindInt64})})
+	l := slog.New(h).With("n", "ctx")
+	l.Info("a", "n", 1)
+	l.Info("b", "n", true)
+	AssertEqual(t, 2, h.Violations())
+}
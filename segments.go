@@ -0,0 +1,147 @@
+package console
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SegmentKind is the part of a record a Segment renders.
+type SegmentKind int
+
+const (
+	// SegmentText is text which isn't part of a field, like spaces and punctuation
+	// between fields, or text styled outside of the theme, like prefixes.
+	SegmentText SegmentKind = iota
+	SegmentTimestamp
+	SegmentLevel
+	SegmentSource
+	// SegmentHeader is a header attr, or a literal in HeaderFormat styled as a header.
+	SegmentHeader
+	SegmentMessage
+	// SegmentAttrKey is an attr's key, with its group prefix, and "=".
+	SegmentAttrKey
+	SegmentAttrValue
+)
+
+var segmentKindNames = []string{"Text", "Timestamp", "Level", "Source", "Header", "Message", "AttrKey", "AttrValue"}
+
+func (k SegmentKind) String() string {
+	if int(k) < len(segmentKindNames) {
+		return segmentKindNames[k]
+	}
+	return "SegmentKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// markerBase is the first SGR parameter used to mark the theme's styles when finding the
+// segments of a record.  Terminals ignore SGR parameters this large.
+const markerBase = 900
+
+// themeStyle is a style field of Theme, for segments.
+type themeStyle struct {
+	index int // of the field in Theme
+	kind  SegmentKind
+	// fallback is the index of the field used when the field is empty, or -1
+	fallback int
+}
+
+var (
+	// markerTheme has a unique marker as each of its styles
+	markerTheme Theme
+	// themeStyles are the style fields of Theme, by their marker
+	themeStyles []themeStyle
+)
+
+func init() {
+	t := reflect.TypeOf(markerTheme)
+	v := reflect.ValueOf(&markerTheme).Elem()
+	attrValue, _ := t.FieldByName("AttrValue")
+	markerTheme.Name = "segments"
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type != reflect.TypeOf(ANSIMod("")) {
+			continue
+		}
+		v.Field(i).SetString("\x1b[" + strconv.Itoa(markerBase+len(themeStyles)) + "m")
+		s := themeStyle{index: i, fallback: -1}
+		switch name := f.Name; {
+		case name == "Timestamp":
+			s.kind = SegmentTimestamp
+		case name == "Header":
+			s.kind = SegmentHeader
+		case name == "Source":
+			s.kind = SegmentSource
+		case strings.HasPrefix(name, "Message"):
+			s.kind = SegmentMessage
+		case name == "AttrKey":
+			s.kind = SegmentAttrKey
+		case strings.HasPrefix(name, "Level"):
+			s.kind = SegmentLevel
+		default:
+			s.kind = SegmentAttrValue
+			switch name {
+			case "AttrValueTime", "AttrValueDuration", "AttrValueBool", "AttrValueNumber":
+				// see Theme.valueStyle
+				s.fallback = attrValue.Index[0]
+			}
+		}
+		themeStyles = append(themeStyles, s)
+	}
+}
+
+// segments renders the record again, with markerTheme, and splits it into segments, with
+// the styles of h's theme.
+func (h *Handler) segments(rec slog.Record) []Segment {
+	m := h.derived.segmenter.Load()
+	if m == nil {
+		m = h.segmenter()
+		h.derived.segmenter.Store(m)
+	}
+	m.shared.width.Store(h.shared.width.Load())
+	enc := m.render(rec)
+	segs := ParseStyled(string(enc.buf))
+	enc.free()
+
+	theme := reflect.ValueOf(h.opts.Theme)
+	for i, seg := range segs {
+		style := seg.Style
+		segs[i].Kind = SegmentText
+		// the innermost style decides the kind
+		if j := strings.LastIndex(string(style), "\x1b["); j >= 0 {
+			n, err := strconv.Atoi(strings.TrimSuffix(string(style[j+2:]), "m"))
+			if n -= markerBase; err == nil && n >= 0 && n < len(themeStyles) {
+				ts := themeStyles[n]
+				segs[i].Kind = ts.kind
+				style = ANSIMod(theme.Field(ts.index).String())
+				if style == "" && ts.fallback >= 0 {
+					style = ANSIMod(theme.Field(ts.fallback).String())
+				}
+			}
+		}
+		if h.opts.NoColor {
+			style = ""
+		}
+		segs[i].Style = style
+	}
+	return segs
+}
+
+// segmenter returns a copy of h which renders with markerTheme, and doesn't share h's
+// state, so rendering with it has no effects.
+func (h *Handler) segmenter() *Handler {
+	opts := h.opts
+	opts.Theme = markerTheme
+	opts.NoColor = false
+	// h.opts are already stable, if they were set to be
+	opts.StableWhenCaptured = false
+	opts.OnRender = nil
+	opts.TrailerChunkSize = 0
+	m := newHandler(io.Discard, &opts)
+	m.groups, m.groupPrefix = h.groups, h.groupPrefix
+	m.attrs = h.attrs
+	m.tenant = h.tenant
+	m.reencodeContext()
+	return m
+}
@@ -0,0 +1,76 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_OnRender(t *testing.T) {
+	theme := NewDefaultTheme()
+	var got []Segment
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{
+		Theme:        theme,
+		HeaderFormat: "%l %m %a",
+		OnRender:     func(segments []Segment) { got = segments },
+	})
+	l := slog.New(h).With("svc", "api")
+	l.Warn("slow", "ms", 12, "err", errors.New("boom"))
+
+	AssertEqual(t, fmt.Sprintf("%q", []Segment{
+		{Kind: SegmentLevel, Style: theme.LevelWarn, Text: "WRN"},
+		{Text: " "},
+		{Kind: SegmentMessage, Style: theme.Message, Text: "slow"},
+		{Text: " "},
+		{Kind: SegmentAttrKey, Style: theme.AttrKey, Text: "svc="},
+		{Kind: SegmentAttrValue, Style: theme.AttrValue, Text: "api"},
+		{Text: " "},
+		{Kind: SegmentAttrKey, Style: theme.AttrKey, Text: "ms="},
+		{Kind: SegmentAttrValue, Style: theme.AttrValue, Text: "12"},
+		{Text: " "},
+		{Kind: SegmentAttrKey, Style: theme.AttrKey, Text: "err="},
+		{Kind: SegmentAttrValue, Style: theme.AttrValueError, Text: "boom"},
+	}), fmt.Sprintf("%q", got))
+
+	// the segments make up the line
+	var text, want strings.Builder
+	for _, s := range got {
+		text.WriteString(s.Text)
+	}
+	for _, s := range ParseStyled(strings.TrimSuffix(buf.String(), "\n")) {
+		want.WriteString(s.Text)
+	}
+	AssertEqual(t, want.String(), text.String())
+}
+
+func TestHandler_OnRender_NoColor(t *testing.T) {
+	var got []Segment
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &HandlerOptions{
+		NoColor:  true,
+		OnRender: func(segments []Segment) { got = segments },
+	})
+	slog.New(h).WithGroup("req").Info("hi", "d", time.Second)
+
+	var text strings.Builder
+	var kinds []string
+	for _, s := range got {
+		AssertEqual(t, ANSIMod(""), s.Style)
+		text.WriteString(s.Text)
+		kinds = append(kinds, s.Kind.String())
+	}
+	AssertEqual(t, strings.TrimSuffix(buf.String(), "\n"), text.String())
+	AssertEqual(t, "Timestamp Text Level Text Message Text AttrKey AttrValue", strings.Join(kinds, " "))
+}
+
+func TestHandler_OnRender_counts(t *testing.T) {
+	// rendering the segments doesn't count the record twice
+	h := NewHandler(&bytes.Buffer{}, &HandlerOptions{OnRender: func([]Segment) {}})
+	slog.New(h).Info("hi")
+	AssertEqual(t, int64(1), h.Metrics().Records)
+}
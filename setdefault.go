@@ -0,0 +1,54 @@
+package console
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// LevelEnv is the environment variable SetDefault reads the level from, like "debug",
+// "warn", or "info+2".
+const LevelEnv = "LOG_LEVEL"
+
+// SetDefault creates a Handler writing to os.Stderr, makes a logger using it the default
+// slog logger with slog.SetDefault, and returns the handler, so it can be adjusted later,
+// e.g. with SetTheme.  opts may be nil.  It isn't modified.
+//
+// Color is disabled if the NO_COLOR environment variable is set, or if stderr isn't a
+// terminal or TERM is "dumb", unless FORCE_COLOR or opts.ForceColor is set.  If opts.Level
+// is nil, the level is read from the LOG_LEVEL environment variable, falling back to info.
+// The level is held in a *slog.LevelVar, which can be changed later through
+// Options().Level.
+func SetDefault(opts *HandlerOptions) *Handler {
+	h := newDefaultHandler(os.Stderr, opts, os.Getenv)
+	slog.SetDefault(slog.New(h))
+	return h
+}
+
+func newDefaultHandler(out io.Writer, opts *HandlerOptions, getenv func(string) string) *Handler {
+	var o HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	switch {
+	case getenv("NO_COLOR") != "":
+		o.NoColor = true
+	case getenv("FORCE_COLOR") != "", o.ForceColor:
+	case !isTerminal(out), getenv("TERM") == "dumb":
+		o.NoColor = true
+	}
+
+	if o.Level == nil {
+		lv := new(slog.LevelVar)
+		if s := getenv(LevelEnv); s != "" {
+			var l slog.Level
+			if err := l.UnmarshalText([]byte(s)); err == nil {
+				lv.Set(l)
+			}
+		}
+		o.Level = lv
+	}
+
+	return NewHandler(out, &o)
+}
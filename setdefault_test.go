@@ -0,0 +1,52 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestNewDefaultHandler(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	var buf bytes.Buffer
+	h := newDefaultHandler(&buf, nil, env(nil))
+	AssertEqual(t, true, h.Options().NoColor)
+	AssertEqual(t, slog.LevelInfo, h.Options().Level.Level())
+
+	h = newDefaultHandler(&buf, nil, env(map[string]string{"FORCE_COLOR": "1", LevelEnv: "debug"}))
+	AssertEqual(t, false, h.Options().NoColor)
+	AssertEqual(t, slog.LevelDebug, h.Options().Level.Level())
+
+	h = newDefaultHandler(&buf, &HandlerOptions{ForceColor: true}, env(nil))
+	AssertEqual(t, false, h.Options().NoColor)
+
+	h = newDefaultHandler(&buf, nil, env(map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1", LevelEnv: "warn+1"}))
+	AssertEqual(t, true, h.Options().NoColor)
+	AssertEqual(t, slog.LevelWarn+1, h.Options().Level.Level())
+
+	// invalid levels are ignored, explicit levels win
+	h = newDefaultHandler(&buf, nil, env(map[string]string{LevelEnv: "loud"}))
+	AssertEqual(t, slog.LevelInfo, h.Options().Level.Level())
+	opts := &HandlerOptions{Level: slog.LevelError}
+	h = newDefaultHandler(&buf, opts, env(map[string]string{LevelEnv: "debug"}))
+	AssertEqual(t, slog.LevelError, h.Options().Level.Level())
+	AssertEqual(t, false, opts.NoColor)
+
+	// the level can be adjusted later
+	h = newDefaultHandler(&buf, &HandlerOptions{HeaderFormat: "%l %m"}, env(nil))
+	slog.New(h).Debug("hidden")
+	h.Options().Level.(*slog.LevelVar).Set(slog.LevelDebug)
+	slog.New(h).Debug("visible")
+	AssertEqual(t, "DBG visible\n", buf.String())
+}
+
+func TestSetDefault(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	h := SetDefault(nil)
+	AssertEqual(t, slog.Handler(h), slog.Default().Handler())
+}
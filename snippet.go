@@ -0,0 +1,74 @@
+package console
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// maxCachedSourceFiles is the number of source files kept in memory
+// for rendering snippets.
+const maxCachedSourceFiles = 16
+
+// sourceFiles caches the lines of source files read for snippets.  Records are
+// usually logged from a handful of files, so a small cache evicting the oldest
+// file is enough.
+var sourceFiles = sourceCache{files: map[string][][]byte{}}
+
+type sourceCache struct {
+	mu    sync.Mutex
+	files map[string][][]byte
+	order []string
+}
+
+// lines returns the lines of the file, or nil if it can't be read.
+func (c *sourceCache) lines(file string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if lines, ok := c.files[file]; ok {
+		return lines
+	}
+
+	// cache failures too, so unreadable files aren't retried on every record
+	var lines [][]byte
+	if b, err := os.ReadFile(file); err == nil {
+		lines = bytes.Split(b, []byte("\n"))
+	}
+
+	if len(c.order) >= maxCachedSourceFiles {
+		delete(c.files, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.files[file] = lines
+	c.order = append(c.order, file)
+	return lines
+}
+
+// writeSourceSnippet writes the given line of file, and the lines immediately
+// before and after it, to buf.  Each line is preceded by a newline.
+func (e *encoder) writeSourceSnippet(buf *buffer, file string, line int) {
+	lines := sourceFiles.lines(file)
+	if line < 1 || line > len(lines) {
+		return
+	}
+
+	first, last := max(line-1, 1), min(line+1, len(lines))
+	width := len(strconv.Itoa(last))
+	for n := first; n <= last; n++ {
+		buf.AppendByte('\n')
+		e.withColor(buf, e.h.opts.Theme.Source, func() {
+			if n == line {
+				buf.AppendString("> ")
+			} else {
+				buf.AppendString("  ")
+			}
+			num := strconv.Itoa(n)
+			buf.Pad(width-len(num), ' ')
+			buf.AppendString(num)
+			buf.AppendString(" | ")
+			buf.Append(bytes.TrimRight(lines[n-1], "\r"))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+func TestHandler_ShowSourceSnippet(t *testing.T) {
+	// the snippet is the line before, the line itself, and the line after
+	// keep the lines around runtime.Caller stable!
+	pc, _, line, _ := runtime.Caller(0)
+	prev := fmt.Sprintf("  %d | \t// keep the lines around runtime.Caller stable!", line-1)
+	cur := fmt.Sprintf("> %d | \tpc, _, line, _ := runtime.Caller(0)", line)
+	next := fmt.Sprintf("  %d | \tprev := fmt.Sprintf(\"  %%d | \\t// keep the lines around runtime.Caller stable!\", line-1)", line+1)
+
+	tests := []handlerTest{
+		{
+			name: "error",
+			lvl:  slog.LevelError,
+			want: "ERR boom\n" + prev + "\n" + cur + "\n" + next + "\n",
+		},
+		{
+			name: "below error",
+			lvl:  slog.LevelWarn,
+			want: "WRN boom\n",
+		},
+		{
+			name: "after multiline attrs",
+			lvl:  slog.LevelError,
+			attrs: []slog.Attr{
+				slog.String("stack", "a\nb"),
+			},
+			want: "ERR boom\n=== stack ===\na\nb\n" + prev + "\n" + cur + "\n" + next + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "boom"
+		tt.pc = pc
+		tt.opts = HandlerOptions{AddSource: true, ShowSourceSnippet: true, NoColor: true, HeaderFormat: "%l %m %a", ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.SourceKey {
+				return slog.Attr{}
+			}
+			return a
+		}}
+		t.Run(tt.name, tt.run)
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		e := newEncoder(NewHandler(nil, &HandlerOptions{NoColor: true}))
+		defer e.free()
+		e.writeSourceSnippet(&e.buf, "does/not/exist.go", 10)
+		AssertEqual(t, "", e.buf.String())
+	})
+}
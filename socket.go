@@ -0,0 +1,251 @@
+package console
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// SocketOptions are options for a SocketWriter.
+type SocketOptions struct {
+	// BufferSize is the number of writes buffered while the socket is disconnected, or
+	// slow.  When the buffer is full, the oldest writes are dropped.  Defaults to 1000.
+	BufferSize int
+
+	// DialTimeout limits how long connecting takes.  Defaults to 5s.
+	DialTimeout time.Duration
+
+	// WriteTimeout limits how long each write to the socket takes, so a peer which stops
+	// reading doesn't stall the writer, and Flush and Close with it.  Defaults to 5s.
+	WriteTimeout time.Duration
+
+	// ReconnectDelay is how long to wait after a failed connection attempt before
+	// trying again.  The delay doubles with each failure, up to 30s.  Defaults to 1s.
+	ReconnectDelay time.Duration
+
+	// OnError is called with errors connecting and writing to the socket.
+	OnError func(error)
+}
+
+// SocketWriter writes to a stream or datagram socket, like a Unix domain socket of a log
+// shipping sidecar.  Writes are buffered, and written in the background, so logging never
+// blocks on the socket.  If connecting or writing fails, the SocketWriter reconnects, and
+// retries the write.  Writes are sent first in, first out, so records are sent in the order
+// the handler wrote them, see StressTest.  When the buffer is full, the oldest writes are
+// dropped, never reordered.  A write which fails after part of it was sent on a stream
+// socket is dropped rather than retried, so a record is never sent twice, or in pieces.
+//
+// Each write is sent as is, so with datagram sockets ("unixgram", "udp"), each record is
+// sent as one datagram.  Use it as the output of a Handler to send rendered lines, ideally
+// with NoColor set, or of slog.NewJSONHandler to send JSON.
+type SocketWriter struct {
+	network, address string
+	opts             SocketOptions
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    [][]byte
+	closed   bool
+	enqueued int64
+	done     int64 // writes which were written or dropped
+	dropped  int64
+	failures int64
+	stopped  chan struct{}
+	closeCh  chan struct{}
+	conn     net.Conn
+}
+
+// NewSocketWriter returns a SocketWriter which connects to address on the named network, as
+// net.Dial does.  It connects in the background, so it doesn't fail if the socket isn't
+// available yet.
+func NewSocketWriter(network, address string, opts *SocketOptions) *SocketWriter {
+	if opts == nil {
+		opts = new(SocketOptions)
+	}
+	w := &SocketWriter{
+		network: network,
+		address: address,
+		opts:    *opts,
+		stopped: make(chan struct{}),
+		closeCh: make(chan struct{}),
+	}
+	if w.opts.BufferSize <= 0 {
+		w.opts.BufferSize = 1000
+	}
+	if w.opts.DialTimeout <= 0 {
+		w.opts.DialTimeout = 5 * time.Second
+	}
+	if w.opts.WriteTimeout <= 0 {
+		w.opts.WriteTimeout = 5 * time.Second
+	}
+	if w.opts.ReconnectDelay <= 0 {
+		w.opts.ReconnectDelay = time.Second
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues p to be written to the socket.  It never blocks, and only fails if the
+// writer is closed.
+func (w *SocketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, net.ErrClosed
+	}
+	if len(w.queue) >= w.opts.BufferSize {
+		w.queue = w.queue[1:]
+		w.dropped++
+		w.done++
+	}
+	w.queue = append(w.queue, append([]byte(nil), p...))
+	w.enqueued++
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// Dropped returns the number of writes dropped because the buffer was full, or because
+// they were only partly sent.
+func (w *SocketWriter) Dropped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return int(w.dropped)
+}
+
+// Flush waits until everything written so far has been written to the socket.  It returns
+// net.ErrClosed if the writer is closed first, or an error if connecting or writing fails
+// while waiting, leaving the rest buffered for retries.
+func (w *SocketWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	target, failures := w.enqueued, w.failures
+	for w.done < target {
+		if w.closed {
+			return net.ErrClosed
+		}
+		if w.failures != failures {
+			return errSocketUnavailable
+		}
+		w.cond.Wait()
+	}
+	if w.failures != failures {
+		// the last write failed, and was dropped
+		return errSocketUnavailable
+	}
+	return nil
+}
+
+// Close writes what's buffered, if the socket is connected, and closes the socket.  Writes
+// still buffered if connecting or writing fails are dropped.
+func (w *SocketWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.closeCh)
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.stopped
+	return nil
+}
+
+type socketError string
+
+func (e socketError) Error() string { return string(e) }
+
+const errSocketUnavailable = socketError("console: socket unavailable")
+
+func (w *SocketWriter) run() {
+	defer close(w.stopped)
+	delay := w.opts.ReconnectDelay
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			w.closeConn()
+			return
+		}
+		p := w.queue[0]
+		w.queue = w.queue[1:]
+		closed := w.closed
+		w.mu.Unlock()
+
+		n, err := w.write(p)
+		if err == nil {
+			delay = w.opts.ReconnectDelay
+			w.mu.Lock()
+			w.done++
+			w.cond.Broadcast()
+			w.mu.Unlock()
+			continue
+		}
+
+		if w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+		w.mu.Lock()
+		w.failures++
+		if closed {
+			// give up on the rest
+			w.dropped += int64(len(w.queue)) + 1
+			w.done += int64(len(w.queue)) + 1
+			w.queue = nil
+		} else if n > 0 {
+			// the peer got part of it: sending it again would duplicate that part,
+			// and sending the rest on a new connection would garble it
+			w.dropped++
+			w.done++
+		} else if len(w.queue) < w.opts.BufferSize {
+			// put it back to retry
+			w.queue = append([][]byte{p}, w.queue...)
+		} else {
+			w.dropped++
+			w.done++
+		}
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if !closed {
+			select {
+			case <-time.After(delay):
+			case <-w.closeCh:
+			}
+			delay = min(delay*2, 30*time.Second)
+		}
+	}
+}
+
+// write writes p to the socket, connecting first if necessary, and returns the number
+// of bytes written.
+func (w *SocketWriter) write(p []byte) (int, error) {
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.address, w.opts.DialTimeout)
+		if err != nil {
+			return 0, err
+		}
+		w.conn = conn
+	}
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.opts.WriteTimeout)); err != nil {
+		w.closeConn()
+		return 0, err
+	}
+	n, err := w.conn.Write(p)
+	if err != nil {
+		w.closeConn()
+		return n, err
+	}
+	return n, nil
+}
+
+func (w *SocketWriter) closeConn() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
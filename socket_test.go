@@ -0,0 +1,158 @@
+package console
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// socketPath returns a path for a unix socket, short enough for the platform's limit.
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "cs")
+	AssertNoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "s")
+}
+
+// acceptLines accepts one connection on l, and sends each line read from it to the channel.
+func acceptLines(t *testing.T, l net.Listener) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(lines)
+			return
+		}
+		defer conn.Close()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	return lines
+}
+
+func TestSocketWriter(t *testing.T) {
+	path := socketPath(t)
+	l, err := net.Listen("unix", path)
+	AssertNoError(t, err)
+	defer l.Close()
+	lines := acceptLines(t, l)
+
+	w := NewSocketWriter("unix", path, nil)
+	logger := slog.New(NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a"}))
+	logger.Info("one", "a", 1)
+	logger.Warn("two")
+	AssertNoError(t, logger.Handler().(*Handler).Flush())
+	AssertNoError(t, w.Close())
+
+	AssertEqual(t, "INF one a=1", <-lines)
+	AssertEqual(t, "WRN two", <-lines)
+
+	_, err = w.Write([]byte("closed\n"))
+	AssertEqual(t, net.ErrClosed, err)
+}
+
+func TestSocketWriter_Reconnect(t *testing.T) {
+	path := socketPath(t)
+	var errs atomic.Int64
+	w := NewSocketWriter("unix", path, &SocketOptions{
+		ReconnectDelay: 10 * time.Millisecond,
+		OnError:        func(error) { errs.Add(1) },
+	})
+	defer w.Close()
+
+	_, err := w.Write([]byte("buffered\n"))
+	AssertNoError(t, err)
+	// nothing is listening yet
+	AssertEqual[error](t, errSocketUnavailable, w.Flush())
+
+	l, err := net.Listen("unix", path)
+	AssertNoError(t, err)
+	defer l.Close()
+	lines := acceptLines(t, l)
+
+	for w.Flush() != nil {
+	}
+	AssertEqual(t, "buffered", <-lines)
+	AssertEqual(t, true, errs.Load() > 0)
+	AssertEqual(t, 0, w.Dropped())
+}
+
+func TestSocketWriter_BufferSize(t *testing.T) {
+	w := NewSocketWriter("unix", socketPath(t), &SocketOptions{BufferSize: 2, ReconnectDelay: time.Hour})
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte("line\n"))
+		AssertNoError(t, err)
+	}
+	AssertEqual(t, true, w.Dropped() >= 3)
+	AssertNoError(t, w.Close())
+	AssertEqual(t, 5, w.Dropped())
+}
+
+func TestSocketWriter_Datagrams(t *testing.T) {
+	path := socketPath(t)
+	conn, err := net.ListenPacket("unixgram", path)
+	AssertNoError(t, err)
+	defer conn.Close()
+
+	w := NewSocketWriter("unixgram", path, nil)
+	logger := slog.New(NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m"}))
+	logger.Info("one")
+	logger.Info("two")
+	AssertNoError(t, w.Close())
+
+	buf := make([]byte, 100)
+	for _, want := range []string{"one\n", "two\n"} {
+		n, _, err := conn.ReadFrom(buf)
+		AssertNoError(t, err)
+		AssertEqual(t, want, string(buf[:n]))
+	}
+}
+
+func TestSocketWriter_WriteTimeout(t *testing.T) {
+	path := socketPath(t)
+	l, err := net.Listen("unix", path)
+	AssertNoError(t, err)
+	defer l.Close()
+	// accept, but never read
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	w := NewSocketWriter("unix", path, &SocketOptions{WriteTimeout: 50 * time.Millisecond, ReconnectDelay: time.Hour})
+	// larger than the socket's buffers, so only part of it is sent before the peer stalls
+	_, err = w.Write(make([]byte, 16<<20))
+	AssertNoError(t, err)
+	AssertEqual[error](t, errSocketUnavailable, w.Flush())
+	// the partly sent write isn't retried
+	AssertEqual(t, 1, w.Dropped())
+
+	done := make(chan struct{})
+	go func() {
+		AssertNoError(t, w.Close())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the stalled peer")
+	}
+}
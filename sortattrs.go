@@ -0,0 +1,90 @@
+package console
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// sortAttrs returns a copy of h without its encoded context, and the context attrs and
+// the record's attrs, nested in the handler's groups, sorted by key.  The copy encodes
+// all the attrs from the root, like reencodeContext.
+func (h *Handler) sortAttrs(rec slog.Record) (*Handler, []slog.Attr) {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+1)
+	attrs = append(attrs, h.attrs...)
+	recAttrs := make([]slog.Attr, 0, rec.NumAttrs())
+	rec.Attrs(func(a slog.Attr) bool {
+		recAttrs = append(recAttrs, a)
+		return true
+	})
+	if h.opts.InlineCollisions != InlineCollisionsKeep {
+		recAttrs = expandInline(recAttrs, h.opts.InlineCollisions)
+	}
+	if h.opts.InterpolateMessage {
+		recAttrs = markRecordAttrs(recAttrs)
+	}
+	attrs = append(attrs, groupAttrs(h.groups, recAttrs)...)
+	attrs = sortedAttrs(attrs)
+
+	return h.root(), attrs
+}
+
+// root returns a copy of h without its groups or context attrs, which renders records
+// as if no attrs had been added with WithAttrs, and no groups opened with WithGroup.
+func (h *Handler) root() *Handler {
+	root := *h
+	root.groups, root.groupPrefix = nil, ""
+	root.context, root.multilineContext = nil, nil
+	root.orderedContext, root.orderedContextAttrs = nil, nil
+	root.attrs = nil
+	root.errorMemo = ""
+	root.defaultsSeen = nil
+	root.headerFields = slices.Clone(h.headerFields)
+	for i := range root.headerFields {
+		root.headerFields[i].memo = ""
+	}
+	return &root
+}
+
+// sortedAttrs returns attrs sorted by key, with the attrs in groups sorted too.  Groups
+// with the same key, like a group from WithGroup and a group in a record with the same
+// name, are merged.  Inline groups are expanded, so their attrs are sorted with the rest.
+func sortedAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(attrs))
+	var groups map[string]int
+	var add func(attrs []slog.Attr)
+	add = func(attrs []slog.Attr) {
+		for _, a := range attrs {
+			a.Value = a.Value.Resolve()
+			if a.Value.Kind() != slog.KindGroup {
+				out = append(out, a)
+				continue
+			}
+			if a.Key == "" {
+				add(a.Value.Group())
+				continue
+			}
+			if i, ok := groups[a.Key]; ok {
+				merged := append(slices.Clip(out[i].Value.Group()), a.Value.Group()...)
+				out[i].Value = slog.GroupValue(merged...)
+				continue
+			}
+			if groups == nil {
+				groups = map[string]int{}
+			}
+			groups[a.Key] = len(out)
+			out = append(out, a)
+		}
+	}
+	add(attrs)
+
+	for i, a := range out {
+		if a.Value.Kind() == slog.KindGroup {
+			out[i].Value = slog.GroupValue(sortedAttrs(a.Value.Group())...)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return out
+}
@@ -0,0 +1,76 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_SortAttrs(t *testing.T) {
+	tests := []handlerTest{
+		{
+			name:  "record attrs",
+			attrs: []slog.Attr{slog.String("c", "3"), slog.String("a", "1"), slog.String("b", "2")},
+			want:  "INF msg a=1 b=2 c=3\n",
+		},
+		{
+			name: "context and record attrs",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("z", "26"), slog.String("b", "ctx")})
+			},
+			attrs: []slog.Attr{slog.String("b", "rec"), slog.String("a", "1")},
+			want:  "INF msg a=1 b=ctx b=rec z=26\n",
+		},
+		{
+			name: "groups",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("y", "1")}).WithGroup("g").WithAttrs([]slog.Attr{slog.String("c", "3")})
+			},
+			attrs: []slog.Attr{slog.String("b", "2"), slog.Group("", slog.String("a", "1"))},
+			want:  "INF msg g.a=1 g.b=2 g.c=3 y=1\n",
+		},
+		{
+			name: "headers and errors from context",
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("id", "x"), slog.Any("err", errors.New("boom"))})
+			},
+			attrs: []slog.Attr{slog.String("b", "2"), slog.String("a", "1")},
+			want:  "INF msg a=1 b=2 err=boom id=x\n",
+		},
+		{
+			name:  "multiline",
+			attrs: []slog.Attr{slog.String("m", "a\nb"), slog.String("a", "1")},
+			want:  "INF msg a=1\n=== m ===\na\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		tt.msg = "msg"
+		tt.opts = HandlerOptions{NoColor: true, HeaderFormat: "%l %m %a", SortAttrs: true}
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestHandler_SortAttrsHeaders(t *testing.T) {
+	handlerTest{
+		opts: HandlerOptions{NoColor: true, HeaderFormat: "%l [%[id]h] %m%{: %e%} %a", SortAttrs: true},
+		handlerFunc: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("id", "x"), slog.Any("err", errors.New("boom"))})
+		},
+		msg:   "msg",
+		attrs: []slog.Attr{slog.String("b", "2"), slog.String("a", "1")},
+		want:  "INF [x] msg: boom a=1 b=2\n",
+	}.run(t)
+}
+
+func TestHandler_StableWhenCaptured(t *testing.T) {
+	var buf bytes.Buffer
+	opts := &HandlerOptions{Theme: NewDefaultTheme(), AddSource: true, StableWhenCaptured: true}
+	h := NewHandler(&buf, opts)
+	slog.New(h).Info("msg", "b", 2, "a", 1)
+	AssertEqual(t, "INF msg a=1 b=2\n", buf.String())
+
+	// the caller's options aren't modified
+	AssertEqual(t, false, opts.NoColor)
+	AssertEqual(t, false, opts.SortAttrs)
+}
@@ -0,0 +1,104 @@
+package console
+
+import (
+	"log/slog"
+	"path"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceURLKey is the key of the attribute holding the link to the source
+// location, when HandlerOptions.SourceURL is set and colors are disabled.
+const SourceURLKey = "source_url"
+
+// mainModule describes the main module: its path, the import path of the main
+// package, and the commit it was built from.  If the build info doesn't record a
+// VCS revision, the module version is used instead, or "HEAD" as a last resort.
+var mainModule = sync.OnceValue(func() (m moduleInfo) {
+	m.commit = "HEAD"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return m
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		m.commit = v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && s.Value != "" {
+			m.commit = s.Value
+		}
+	}
+	m.path = info.Main.Path
+	m.mainPackage = info.Main.Path
+	if strings.HasPrefix(info.Path, info.Main.Path+"/") {
+		// e.g. a command in a subdirectory of the module, like cmd/tool
+		m.mainPackage = info.Path
+	}
+	return m
+})
+
+// moduleInfo is the main module, as described by the build info.
+type moduleInfo struct {
+	path, mainPackage, commit string
+}
+
+// sourceURL expands the SourceURL template for src.  Returns "" if there is no
+// template, or if src isn't part of the main module.
+//
+// The file path is derived from the package path of the function, relative to the
+// main module, rather than from the file path on disk, so it works the same
+// regardless of where, or with what flags, the binary was built.
+func (e *encoder) sourceURL(src *slog.Source) string {
+	tmpl := e.h.opts.SourceURL
+	if tmpl == "" || src.Function == "" {
+		return ""
+	}
+	return expandSourceURL(tmpl, mainModule(), src)
+}
+
+// expandSourceURL expands tmpl for src, which is located relative to mod.
+func expandSourceURL(tmpl string, mod moduleInfo, src *slog.Source) string {
+	pkg := funcPackage(src.Function)
+	if pkg == "main" {
+		// functions in package main are named "main.f", rather than by import path
+		pkg = mod.mainPackage
+	}
+	var dir string
+	switch {
+	case mod.path == "":
+		return ""
+	case pkg == mod.path:
+	case strings.HasPrefix(pkg, mod.path+"/"):
+		dir = pkg[len(mod.path)+1:]
+	default:
+		// not our code
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{commit}", mod.commit,
+		"{file}", path.Join(dir, path.Base(src.File)),
+		"{line}", strconv.Itoa(src.Line),
+	).Replace(tmpl)
+}
+
+// funcPackage returns the import path of the package of a fully qualified
+// function name, like "github.com/org/repo/pkg.(*T).Method".
+func funcPackage(fn string) string {
+	slash := strings.LastIndexByte(fn, '/')
+	if dot := strings.IndexByte(fn[slash+1:], '.'); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
+
+// writeHyperlink wraps the output of f in an OSC 8 terminal hyperlink to url.
+func writeHyperlink(buf *buffer, url string, f func()) {
+	buf.AppendString("\x1b]8;;")
+	buf.AppendString(url)
+	buf.AppendString("\x1b\\")
+	f()
+	buf.AppendString("\x1b]8;;\x1b\\")
+}
@@ -0,0 +1,66 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+func TestHandler_SourceURL(t *testing.T) {
+	pc, _, line, _ := runtime.Caller(0)
+	url := fmt.Sprintf("https://example.com/blob/HEAD/sourcelink_test.go#L%d", line)
+	source := fmt.Sprintf("sourcelink_test.go:%d", line)
+
+	tests := []handlerTest{
+		{
+			name: "no color adds attr",
+			opts: HandlerOptions{NoColor: true, HeaderFormat: "%l %s %m %a"},
+			want: fmt.Sprintf("INF %s linked source_url=%s\n", source, url),
+		},
+		{
+			name: "hyperlink",
+			opts: HandlerOptions{HeaderFormat: "%s", Theme: Theme{Name: "none"}},
+			want: "\x1b]8;;" + url + "\x1b\\" + source + "\x1b]8;;\x1b\\\n",
+		},
+		{
+			name: "hyperlink in source attr",
+			opts: HandlerOptions{HeaderFormat: "%m %a", Theme: Theme{Name: "none"}},
+			want: "linked source=\x1b]8;;" + url + "\x1b\\" + source + "\x1b]8;;\x1b\\\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "linked"
+		tt.pc = pc
+		tt.opts.AddSource = true
+		tt.opts.TruncateSourcePath = 1
+		tt.opts.SourceURL = "https://example.com/blob/{commit}/{file}#L{line}"
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestSourceURL_OtherModules(t *testing.T) {
+	e := newEncoder(NewHandler(nil, &HandlerOptions{SourceURL: "{file}"}))
+	defer e.free()
+
+	AssertEqual(t, "", e.sourceURL(&slog.Source{Function: "log/slog.(*Logger).Info", File: "/go/src/log/slog/logger.go", Line: 1}))
+	AssertEqual(t, "", e.sourceURL(&slog.Source{Function: "github.com/ansel1/console-slogger.Foo", File: "/x/foo.go", Line: 1}))
+	AssertEqual(t, "internal/flags.go", e.sourceURL(&slog.Source{Function: "github.com/ansel1/console-slog/internal.(*T).Foo", File: "/x/internal/flags.go", Line: 1}))
+}
+
+func TestSourceURL_MainPackage(t *testing.T) {
+	src := &slog.Source{Function: "main.main", File: "/x/main.go", Line: 3}
+
+	mod := moduleInfo{path: "example.com/tool", mainPackage: "example.com/tool", commit: "abc"}
+	AssertEqual(t, "abc/main.go#L3", expandSourceURL("{commit}/{file}#L{line}", mod, src))
+
+	mod.mainPackage = "example.com/tool/cmd/tool"
+	AssertEqual(t, "abc/cmd/tool/main.go#L3", expandSourceURL("{commit}/{file}#L{line}", mod, src))
+}
+
+func TestFuncPackage(t *testing.T) {
+	AssertEqual(t, "github.com/org/repo/pkg", funcPackage("github.com/org/repo/pkg.(*T).Method"))
+	AssertEqual(t, "github.com/org/repo", funcPackage("github.com/org/repo.Func.func1"))
+	AssertEqual(t, "main", funcPackage("main.main"))
+}
@@ -0,0 +1,109 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/ansel1/console-slog/internal"
+)
+
+// SpanEventFunc records a log record as an event on the tracing span active in ctx.  attrs
+// are all the record's attrs, including those added with WithAttrs, flattened: keys of
+// attrs in groups are joined with ".", and LogValuers are resolved.
+type SpanEventFunc func(ctx context.Context, r slog.Record, attrs []slog.Attr)
+
+// SpanEvents returns a Middleware which passes each record to fn, as well as to the next
+// handler, so tracing backends can record logs as span events without instrumenting the code
+// twice.  The package doesn't depend on any tracing library.  With OpenTelemetry, fn might
+// look like:
+//
+//	func(ctx context.Context, r slog.Record, attrs []slog.Attr) {
+//		span := trace.SpanFromContext(ctx)
+//		if !span.IsRecording() {
+//			return
+//		}
+//		kvs := make([]attribute.KeyValue, 0, len(attrs)+1)
+//		kvs = append(kvs, attribute.String("level", r.Level.String()))
+//		for _, a := range attrs {
+//			kvs = append(kvs, attribute.String(a.Key, a.Value.String()))
+//		}
+//		span.AddEvent(r.Message, trace.WithTimestamp(r.Time), trace.WithAttributes(kvs...))
+//	}
+//
+// Only records enabled by the next handler are passed to fn.
+func SpanEvents(fn SpanEventFunc) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &spanEventHandler{next: next, fn: fn}
+	}
+}
+
+type spanEventHandler struct {
+	next   slog.Handler
+	fn     SpanEventFunc
+	prefix string
+	attrs  []slog.Attr
+}
+
+func (h *spanEventHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *spanEventHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, len(h.attrs), len(h.attrs)+r.NumAttrs())
+	copy(attrs, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlattened(attrs, h.prefix, a)
+		return true
+	})
+	h.fn(ctx, r, attrs)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *spanEventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendFlattened(h2.attrs, h.prefix, a)
+	}
+	h2.next = h.next.WithAttrs(attrs)
+	return &h2
+}
+
+func (h *spanEventHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = internal.JoinKey(h.prefix, name)
+	h2.next = h.next.WithGroup(name)
+	return &h2
+}
+
+// Flush flushes the next handler, if it has a Flush method.
+func (h *spanEventHandler) Flush() error {
+	if f, ok := h.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// appendFlattened appends a to attrs, replacing groups with their attrs, with keys
+// joined to the group's key with ".".  Empty attrs are dropped.
+func appendFlattened(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Equal(slog.Attr{}) {
+			return attrs
+		}
+		a.Key = internal.JoinKey(prefix, a.Key)
+		return append(attrs, a)
+	}
+	if a.Key != "" {
+		prefix = internal.JoinKey(prefix, a.Key)
+	}
+	for _, ga := range a.Value.Group() {
+		attrs = appendFlattened(attrs, prefix, ga)
+	}
+	return attrs
+}
@@ -0,0 +1,55 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type spanEvent struct {
+	msg   string
+	attrs []slog.Attr
+}
+
+func TestSpanEvents(t *testing.T) {
+	type spanKey struct{}
+	var events []spanEvent
+	fn := func(ctx context.Context, r slog.Record, attrs []slog.Attr) {
+		if ctx.Value(spanKey{}) == nil {
+			return
+		}
+		events = append(events, spanEvent{r.Message, attrs})
+	}
+
+	var buf bytes.Buffer
+	h := Chain(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a"}), SpanEvents(fn))
+	logger := slog.New(h).With("a", 1).WithGroup("req")
+
+	ctx := context.WithValue(context.Background(), spanKey{}, true)
+	logger.InfoContext(ctx, "hello", "method", "GET", slog.Group("user", "id", 5))
+	logger.InfoContext(context.Background(), "no span")
+	logger.DebugContext(ctx, "disabled")
+
+	AssertEqual(t, "hello a=1 req.method=GET req.user.id=5\nno span a=1\n", buf.String())
+	AssertEqual(t, 1, len(events))
+	AssertEqual(t, "hello", events[0].msg)
+	want := []slog.Attr{
+		slog.Int("a", 1),
+		slog.String("req.method", "GET"),
+		slog.Int("req.user.id", 5),
+	}
+	AssertEqual(t, len(want), len(events[0].attrs))
+	for i := range want {
+		AssertEqual(t, true, want[i].Equal(events[0].attrs[i]))
+	}
+}
+
+func TestAppendFlattened(t *testing.T) {
+	attrs := appendFlattened(nil, "req", slog.Group("", slog.Bool("inline", true), slog.Attr{}, slog.Group("user", "id", 5)))
+	want := []slog.Attr{slog.Bool("req.inline", true), slog.Int("req.user.id", 5)}
+	AssertEqual(t, len(want), len(attrs))
+	for i := range want {
+		AssertEqual(t, true, want[i].Equal(attrs[i]))
+	}
+}
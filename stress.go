@@ -0,0 +1,114 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StressOptions are options for StressTest.
+type StressOptions struct {
+	// Goroutines is the number of goroutines logging at once.  Defaults to 8.
+	Goroutines int
+	// Records is the number of records each goroutine logs.  Defaults to 1000.
+	Records int
+}
+
+// StressTest checks that a custom writer keeps records intact when they're logged from
+// many goroutines at once.  It logs records concurrently through a Handler writing to w,
+// flushes the handler, then reads the output back with output, and returns an error
+// describing the first problem it finds: a line which isn't exactly one record, because
+// records were interleaved or split, a record which is missing or repeated, or records from
+// one goroutine which appear out of the order it logged them in.
+//
+// The Handler holds a lock while it writes each record, and writes each record with as few
+// writes as the writer accepts, so a writer which writes what it's given, in the order it's
+// given, passes.  Records from one goroutine are always written in the order they're
+// logged; the order of records from different goroutines is the order they took the lock.
+// Asynchronous writers, like SocketWriter, must keep that order, writing first in, first
+// out.  Writers which drop records, like a SocketWriter with a full buffer, fail the test.
+func StressTest(w io.Writer, output func() ([]byte, error), opts *StressOptions) error {
+	var o StressOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Goroutines <= 0 {
+		o.Goroutines = 8
+	}
+	if o.Records <= 0 {
+		o.Records = 1000
+	}
+
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a"})
+	logger := slog.New(h)
+	var wg sync.WaitGroup
+	for g := 0; g < o.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < o.Records; n++ {
+				logger.Info("stress", "g", g, "n", n, "pad", stressPad(g, n))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := h.Flush(); err != nil {
+		return fmt.Errorf("flushing: %w", err)
+	}
+
+	out, err := output()
+	if err != nil {
+		return fmt.Errorf("reading output: %w", err)
+	}
+	next := make([]int, o.Goroutines)
+	lines := bytes.Split(bytes.TrimSuffix(out, []byte("\n")), []byte("\n"))
+	if len(out) == 0 {
+		lines = nil
+	}
+	for i, line := range lines {
+		g, n, ok := parseStressLine(string(line))
+		if !ok || g >= o.Goroutines {
+			return fmt.Errorf("line %d isn't one record, records were interleaved or split: %q", i+1, line)
+		}
+		if n != next[g] {
+			return fmt.Errorf("line %d: goroutine %d's record %d, expected record %d, records were lost or reordered", i+1, g, n, next[g])
+		}
+		next[g]++
+	}
+	for g, n := range next {
+		if n != o.Records {
+			return fmt.Errorf("goroutine %d: %d of %d records were written", g, n, o.Records)
+		}
+	}
+	return nil
+}
+
+// stressPad returns a value which varies the length of stress records, made of a
+// letter for the goroutine, so interleaved records are easy to spot.
+func stressPad(g, n int) string {
+	return strings.Repeat(string(rune('a'+g%26)), 1+(n*37+g)%200)
+}
+
+// parseStressLine parses a line of StressTest's output, checking it's exactly one record.
+func parseStressLine(line string) (g, n int, ok bool) {
+	fields := strings.Split(line, " ")
+	if len(fields) != 4 || fields[0] != "stress" {
+		return 0, 0, false
+	}
+	gs, gok := strings.CutPrefix(fields[1], "g=")
+	ns, nok := strings.CutPrefix(fields[2], "n=")
+	pad, pok := strings.CutPrefix(fields[3], "pad=")
+	if !gok || !nok || !pok {
+		return 0, 0, false
+	}
+	g, gerr := strconv.Atoi(gs)
+	n, nerr := strconv.Atoi(ns)
+	if gerr != nil || nerr != nil || g < 0 || n < 0 || pad != stressPad(g, n) {
+		return 0, 0, false
+	}
+	return g, n, true
+}
@@ -0,0 +1,87 @@
+package console
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStressTest(t *testing.T) {
+	var buf bytes.Buffer
+	output := func() ([]byte, error) { return buf.Bytes(), nil }
+	AssertNoError(t, StressTest(&buf, output, nil))
+
+	buf.Reset()
+	AssertNoError(t, StressTest(NewLineBufferedWriter(&buf), output, &StressOptions{Goroutines: 4, Records: 200}))
+}
+
+func TestStressTest_SocketWriter(t *testing.T) {
+	path := socketPath(t)
+	l, err := net.Listen("unix", path)
+	AssertNoError(t, err)
+	defer l.Close()
+	lines := acceptLines(t, l)
+	// read the lines as they're sent, so the socket doesn't back up
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		for line := range lines {
+			out.WriteString(line + "\n")
+		}
+		close(done)
+	}()
+
+	opts := &StressOptions{Goroutines: 4, Records: 100}
+	w := NewSocketWriter("unix", path, &SocketOptions{BufferSize: opts.Goroutines * opts.Records})
+	AssertNoError(t, StressTest(w, func() ([]byte, error) {
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		<-done
+		return out.Bytes(), nil
+	}, opts))
+}
+
+// chattyWriter writes each byte separately, unlocked, from its caller's goroutine, so
+// concurrent writes interleave unless the handler serializes them.
+type chattyWriter struct {
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func (w *chattyWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.mu.Lock()
+		w.out.WriteByte(b)
+		w.mu.Unlock()
+	}
+	return len(p), nil
+}
+
+func TestStressTest_Failures(t *testing.T) {
+	// the handler serializes writes, even to writers which don't
+	w := &chattyWriter{}
+	AssertNoError(t, StressTest(w, func() ([]byte, error) { return w.out.Bytes(), nil }, &StressOptions{Records: 100}))
+
+	// lost records
+	var n int
+	var buf bytes.Buffer
+	lossy := writerFunc(func(p []byte) (int, error) {
+		if n++; n%10 != 0 {
+			buf.Write(p)
+		}
+		return len(p), nil
+	})
+	err := StressTest(lossy, func() ([]byte, error) { return buf.Bytes(), nil }, &StressOptions{Goroutines: 1, Records: 20})
+	AssertEqual(t, "line 10: goroutine 0's record 10, expected record 9, records were lost or reordered", err.Error())
+
+	// interleaved records
+	err = StressTest(&bytes.Buffer{}, func() ([]byte, error) {
+		return []byte("stress g=0 n=0 pad=aaaa stress g=1\n"), nil
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "line 1 isn't one record") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package console
+
+import (
+	"io"
+	"slices"
+	"sync"
+)
+
+// StripANSIWriter is a writer which removes ANSI escape sequences from everything
+// written through it before passing it on to the underlying writer.  It can be used
+// to capture plain text from a Handler with color enabled, e.g. in tests.
+//
+// Escape sequences split across writes are held back until they are complete.
+type StripANSIWriter struct {
+	w       io.Writer
+	mu      sync.Mutex
+	pending []byte
+	buf     []byte
+}
+
+// NewStripANSIWriter returns a StripANSIWriter writing to w.
+func NewStripANSIWriter(w io.Writer) *StripANSIWriter {
+	return &StripANSIWriter{w: w}
+}
+
+// Write implements io.Writer.  If the underlying writer fails, or writes less than it was
+// given, nothing of p is kept, so the write can be retried.  Short writes fail with
+// io.ErrShortWrite.
+func (s *StripANSIWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := p
+	if len(s.pending) > 0 {
+		// pending is only replaced once the write succeeds
+		b = append(slices.Clip(s.pending), p...)
+	}
+	n := incompleteEscape(b)
+	s.buf = appendStripped(s.buf[:0], b[:n])
+
+	if len(s.buf) > 0 {
+		m, err := s.w.Write(s.buf)
+		if err == nil && m < len(s.buf) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	// copy the incomplete escape, since b might be p
+	s.pending = append(s.pending[:0], b[n:]...)
+	return len(p), nil
+}
+
+// incompleteEscape returns the offset of an unterminated escape sequence at
+// the end of b, or len(b) if there isn't one.
+func incompleteEscape(b []byte) int {
+	for i := 0; i < len(b); {
+		if b[i] != '\x1b' {
+			i++
+			continue
+		}
+		if i == len(b)-1 {
+			return i
+		}
+		n := escapeLen(b[i:])
+		if i+n == len(b) && !escapeTerminated(b[i:]) {
+			return i
+		}
+		i += n
+	}
+	return len(b)
+}
+
+// escapeTerminated reports whether the escape sequence b is complete.
+func escapeTerminated(b []byte) bool {
+	last := b[len(b)-1]
+	switch b[1] {
+	case '[':
+		return len(b) > 2 && last >= 0x40 && last <= 0x7e
+	case ']':
+		return len(b) > 2 && (last == '\a' || (last == '\\' && b[len(b)-2] == '\x1b'))
+	default:
+		return true
+	}
+}
@@ -0,0 +1,68 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestStripANSIWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(NewStripANSIWriter(&buf), &HandlerOptions{Theme: NewDefaultTheme(), HeaderFormat: "%l %m %a"}))
+	l.Error("hello", "foo", "bar")
+	AssertEqual(t, "ERR hello foo=bar\n", buf.String())
+}
+
+func TestStripANSIWriter_SplitWrites(t *testing.T) {
+	red := string(ToANSICode(Red))
+	reset := string(ResetMod)
+	link := "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"
+	in := red + "hello" + reset + " " + link + "\x1b[1m!" + reset + "\n"
+
+	// write the input split at every possible offset
+	for i := 0; i <= len(in); i++ {
+		var buf bytes.Buffer
+		w := NewStripANSIWriter(&buf)
+		n, err := w.Write([]byte(in[:i]))
+		AssertNoError(t, err)
+		AssertEqual(t, i, n)
+		_, err = w.Write([]byte(in[i:]))
+		AssertNoError(t, err)
+		AssertEqual(t, "hello link!\n", buf.String())
+	}
+}
+
+func TestStripANSIWriter_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	errBoom := errors.New("boom")
+	fail := errBoom
+	w := NewStripANSIWriter(writerFunc(func(b []byte) (int, error) {
+		if fail != nil {
+			return 0, fail
+		}
+		return buf.Write(b)
+	}))
+	red := string(ToANSICode(Red))
+
+	// an escape held back from a failed write isn't doubled when the write is retried
+	_, err := w.Write([]byte("a" + red[:2]))
+	AssertEqual(t, errBoom, err)
+	fail = nil
+	_, err = w.Write([]byte("a" + red[:2]))
+	AssertNoError(t, err)
+	fail = errBoom
+	n, err := w.Write([]byte(red[2:] + "b\n"))
+	AssertEqual(t, errBoom, err)
+	AssertEqual(t, 0, n)
+	fail = nil
+	_, err = w.Write([]byte(red[2:] + "b\n"))
+	AssertNoError(t, err)
+	AssertEqual(t, "ab\n", buf.String())
+
+	// short writes are errors
+	w = NewStripANSIWriter(writerFunc(func(b []byte) (int, error) { return len(b) - 1, nil }))
+	_, err = w.Write([]byte("hi\n"))
+	AssertEqual(t, io.ErrShortWrite, err)
+}
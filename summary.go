@@ -0,0 +1,26 @@
+package console
+
+// writeSummary writes the counts of errors and warnings, like "2 errors, 1 warning".
+// Writes nothing if there were none.
+func (e *encoder) writeSummary() {
+	warnings, errors := e.h.Counts()
+	writeCount := func(n int, noun string, style ANSIMod) {
+		e.withColor(&e.buf, style, func() {
+			e.buf.AppendInt(int64(n))
+			e.buf.AppendByte(' ')
+			e.buf.AppendString(noun)
+			if n != 1 {
+				e.buf.AppendByte('s')
+			}
+		})
+	}
+	if errors > 0 {
+		writeCount(errors, "error", e.h.opts.Theme.LevelError)
+	}
+	if warnings > 0 {
+		if errors > 0 {
+			e.buf.AppendString(", ")
+		}
+		writeCount(warnings, "warning", e.h.opts.Theme.LevelWarn)
+	}
+}
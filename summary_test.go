@@ -0,0 +1,64 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_Summary(t *testing.T) {
+	tests := []struct {
+		name             string
+		warnings, errors int
+		want             string
+	}{
+		{name: "none", want: ""},
+		{name: "singular", warnings: 1, errors: 1, want: "1 error, 1 warning\n"},
+		{name: "plural", warnings: 5, errors: 2, want: "2 errors, 5 warnings\n"},
+		{name: "only warnings", warnings: 2, want: "2 warnings\n"},
+		{name: "only errors", errors: 3, want: "3 errors\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			h := NewHandler(&buf, &HandlerOptions{NoColor: true, PrintSummary: true, HeaderFormat: "%m"})
+			logger := slog.New(h)
+			for i := 0; i < tt.warnings; i++ {
+				logger.Warn("w")
+			}
+			for i := 0; i < tt.errors; i++ {
+				logger.With("a", "b").Error("e")
+			}
+			logger.Info("i")
+
+			warnings, errors := h.Counts()
+			AssertEqual(t, tt.warnings, warnings)
+			AssertEqual(t, tt.errors, errors)
+
+			buf.Reset()
+			AssertNoError(t, h.Close())
+			AssertNoError(t, h.Close())
+			AssertEqual(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestHandler_CloseColors(t *testing.T) {
+	theme := NewDefaultTheme()
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{PrintSummary: true, Theme: theme})
+	h.shared.warnings.Add(2)
+	h.shared.errors.Add(1)
+	AssertNoError(t, h.Close())
+	AssertEqual(t, styled("1 error", theme.LevelError)+", "+styled("2 warnings", theme.LevelWarn)+"\n", buf.String())
+}
+
+func TestHandler_CloseTransient(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m", TransientKey: "transient"})
+	h.tty = true
+	slog.New(h).Info("working", "transient", true)
+	AssertNoError(t, h.Close())
+	AssertEqual(t, "working\n", buf.String())
+}
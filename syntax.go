@@ -0,0 +1,214 @@
+package console
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// SQL is a SQL query.  When logged as an attribute value, its keywords, strings, and
+// comments are highlighted with the Theme's Syntax styles.  Queries are usually multiline,
+// so they're normally printed in the multiline section at the end of the log line.
+//
+//	logger.Error("query failed", "query", console.SQL(q), "err", err)
+//
+// With HandlerOptions.HighlightSyntax, plain strings are highlighted too, if their keys
+// are SQLKey or "query", or if they're multiline and look like SQL.
+type SQL string
+
+// XML is an XML document.  When logged as an attribute value, its tags, attribute values,
+// and comments are highlighted with the Theme's Syntax styles, like SQL.  With
+// HandlerOptions.HighlightSyntax, plain strings are highlighted too, if their keys are
+// XMLKey, or if they're multiline and look like XML.
+type XML string
+
+// SQLKey and XMLKey are the conventional attribute keys for SQL and XML.
+const (
+	SQLKey = "sql"
+	XMLKey = "xml"
+)
+
+type syntax int
+
+const (
+	syntaxNone syntax = iota
+	syntaxSQL
+	syntaxXML
+)
+
+// syntaxValue returns the text and syntax to highlight the attr with, if it should be.
+func (e *encoder) syntaxValue(a slog.Attr) (string, syntax) {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case SQL:
+			return string(v), syntaxSQL
+		case XML:
+			return string(v), syntaxXML
+		}
+	case slog.KindString:
+		if !e.h.opts.HighlightSyntax {
+			break
+		}
+		s := a.Value.String()
+		switch {
+		case a.Key == SQLKey || a.Key == "query":
+			return s, syntaxSQL
+		case a.Key == XMLKey:
+			return s, syntaxXML
+		case strings.IndexByte(s, '\n') < 0:
+			// only multiline values are sniffed
+		case looksLikeSQL(s):
+			return s, syntaxSQL
+		case looksLikeXML(s):
+			return s, syntaxXML
+		}
+	}
+	return "", syntaxNone
+}
+
+// sqlStatements are the keywords SQL statements start with, for sniffing.
+var sqlStatements = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "CREATE", "ALTER", "DROP"}
+
+func looksLikeSQL(s string) bool {
+	word, _, _ := strings.Cut(strings.TrimSpace(s), " ")
+	word, _, _ = strings.Cut(word, "\n")
+	for _, kw := range sqlStatements {
+		if strings.EqualFold(word, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func looksLikeXML(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "<") && strings.HasSuffix(s, ">")
+}
+
+// sqlKeywords are the SQL keywords which are highlighted.
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		ADD ALL ALTER AND AS ASC BETWEEN BY CASE CREATE CROSS DEFAULT DELETE DESC DISTINCT
+		DROP ELSE END EXISTS FROM FULL GROUP HAVING IN INDEX INNER INSERT INTO IS JOIN KEY
+		LEFT LIKE LIMIT NOT NULL OFFSET ON OR ORDER OUTER PRIMARY RETURNING RIGHT SELECT SET
+		TABLE THEN UNION UPDATE USING VALUES WHEN WHERE WITH`) {
+		sqlKeywords[kw] = true
+	}
+}
+
+// writeSyntax writes s, highlighted as the syntax.  Values are scrubbed first, if
+// ScrubURLCredentials or MaskTokens are set.
+func (e *encoder) writeSyntax(buf *buffer, s string, syn syntax) {
+	if e.h.opts.ScrubURLCredentials || e.h.opts.MaskTokens {
+		var scrubbed buffer
+		e.appendScrubbed(&scrubbed, s)
+		s = string(scrubbed)
+	}
+	switch syn {
+	case syntaxSQL:
+		e.writeSQL(buf, s)
+	case syntaxXML:
+		e.writeXML(buf, s)
+	}
+}
+
+func (e *encoder) writeSQL(buf *buffer, s string) {
+	theme := &e.h.opts.Theme
+	for len(s) > 0 {
+		var n int
+		var style ANSIMod
+		switch c := s[0]; {
+		case strings.HasPrefix(s, "--"):
+			n = strings.IndexByte(s, '\n')
+			style = theme.SyntaxComment
+		case strings.HasPrefix(s, "/*"):
+			if n = strings.Index(s[2:], "*/"); n >= 0 {
+				n += 4
+			}
+			style = theme.SyntaxComment
+		case c == '\'':
+			// quotes are escaped by doubling them
+			n = 1
+			for n < len(s) {
+				if s[n] == '\'' {
+					if n+1 < len(s) && s[n+1] == '\'' {
+						n += 2
+						continue
+					}
+					n++
+					break
+				}
+				n++
+			}
+			style = theme.SyntaxString
+		case isWordByte(c):
+			for n < len(s) && isWordByte(s[n]) {
+				n++
+			}
+			if sqlKeywords[strings.ToUpper(s[:n])] {
+				style = theme.SyntaxKeyword
+			}
+		default:
+			n = 1
+		}
+		if n < 0 || n > len(s) {
+			n = len(s)
+		}
+		e.writeColoredString(buf, s[:n], style)
+		s = s[n:]
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (e *encoder) writeXML(buf *buffer, s string) {
+	theme := &e.h.opts.Theme
+	inTag := false
+	for len(s) > 0 {
+		var n int
+		var style ANSIMod
+		switch c := s[0]; {
+		case strings.HasPrefix(s, "<!--"):
+			if n = strings.Index(s, "-->"); n >= 0 {
+				n += 3
+			}
+			style = theme.SyntaxComment
+		case c == '<':
+			// the tag name, with its "<", "</", or "<?"
+			n = 1
+			for n < len(s) && !isXMLSpace(s[n]) && s[n] != '>' && (s[n] != '/' || n == 1) {
+				n++
+			}
+			style = theme.SyntaxKeyword
+			inTag = true
+		case inTag && (c == '>' || strings.HasPrefix(s, "/>") || strings.HasPrefix(s, "?>")):
+			n = strings.IndexByte(s, '>') + 1
+			style = theme.SyntaxKeyword
+			inTag = false
+		case inTag && (c == '"' || c == '\''):
+			if n = strings.IndexByte(s[1:], c); n >= 0 {
+				n += 2
+			}
+			style = theme.SyntaxString
+		default:
+			// text, or attribute names, up to the next markup
+			n = 1
+			for n < len(s) && s[n] != '<' && !(inTag && (s[n] == '>' || s[n] == '/' || s[n] == '?' || s[n] == '"' || s[n] == '\'')) {
+				n++
+			}
+		}
+		if n < 0 || n > len(s) {
+			n = len(s)
+		}
+		e.writeColoredString(buf, s[:n], style)
+		s = s[n:]
+	}
+}
+
+func isXMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,83 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_SQL(t *testing.T) {
+	theme := NewDefaultTheme()
+	kw := func(s string) string { return styled(s, theme.SyntaxKeyword) }
+	str := func(s string) string { return styled(s, theme.SyntaxString) }
+	comment := func(s string) string { return styled(s, theme.SyntaxComment) }
+
+	handlerTest{
+		opts:  HandlerOptions{HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Any("q", SQL("select id from users where name = 'o''brien' -- x\nlimit 1"))},
+		want: "\n" + styled("=== q ===\n", theme.AttrKey) +
+			kw("select") + " id " + kw("from") + " users " + kw("where") + " name = " + str("'o''brien'") + " " + comment("-- x") +
+			"\n" + kw("limit") + " 1\n",
+	}.run(t)
+
+	// plain strings are only highlighted with HighlightSyntax
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.String(SQLKey, "SELECT 1")},
+		want:  "sql=SELECT 1\n",
+	}.run(t)
+	handlerTest{
+		opts:  HandlerOptions{HeaderFormat: "%a", HighlightSyntax: true},
+		attrs: []slog.Attr{slog.String(SQLKey, "SELECT /* c */ 1")},
+		want:  styled("sql=", theme.AttrKey) + kw("SELECT") + " " + comment("/* c */") + " 1\n",
+	}.run(t)
+
+	// sniffed
+	handlerTest{
+		opts:  HandlerOptions{HeaderFormat: "%a", HighlightSyntax: true},
+		attrs: []slog.Attr{slog.String("stmt", "UPDATE t\nSET a = 1")},
+		want:  "\n" + styled("=== stmt ===\n", theme.AttrKey) + kw("UPDATE") + " t\n" + kw("SET") + " a = 1\n",
+	}.run(t)
+
+	// scrubbed before highlighting
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a", ScrubURLCredentials: true},
+		attrs: []slog.Attr{slog.Any("q", SQL("SELECT 'postgres://u:pw@db/x'"))},
+		want:  "q=SELECT 'postgres://u:xxxxx@db/x'\n",
+	}.run(t)
+}
+
+func TestHandler_XML(t *testing.T) {
+	theme := NewDefaultTheme()
+	kw := func(s string) string { return styled(s, theme.SyntaxKeyword) }
+	str := func(s string) string { return styled(s, theme.SyntaxString) }
+	comment := func(s string) string { return styled(s, theme.SyntaxComment) }
+
+	handlerTest{
+		opts:  HandlerOptions{HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Any("doc", XML(`<a id="1"><!-- c --><b/>text</a>`))},
+		want: styled("doc=", theme.AttrKey) +
+			kw("<a") + " id=" + str(`"1"`) + kw(">") + comment("<!-- c -->") + kw("<b") + kw("/>") + "text" + kw("</a") + kw(">") + "\n",
+	}.run(t)
+
+	// sniffed
+	handlerTest{
+		opts:  HandlerOptions{HeaderFormat: "%a", HighlightSyntax: true},
+		attrs: []slog.Attr{slog.String("body", "<a>\n</a>")},
+		want:  "\n" + styled("=== body ===\n", theme.AttrKey) + kw("<a") + kw(">") + "\n" + kw("</a") + kw(">") + "\n",
+	}.run(t)
+
+	// unterminated markup doesn't lose text
+	handlerTest{
+		opts:  HandlerOptions{NoColor: true, HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Any("doc", XML(`<a x="1`))},
+		want:  "doc=<a x=\"1\n",
+	}.run(t)
+}
+
+func TestLooksLikeSQL(t *testing.T) {
+	AssertEqual(t, true, looksLikeSQL("  select *\nfrom t"))
+	AssertEqual(t, true, looksLikeSQL("WITH x AS (select 1)"))
+	AssertEqual(t, false, looksLikeSQL("selected items"))
+	AssertEqual(t, true, looksLikeXML("<a>\n</a>"))
+	AssertEqual(t, false, looksLikeXML("a < b > c"))
+}
@@ -0,0 +1,182 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TableValue is an attribute value which renders a slice of structs or maps as an
+// aligned table.  Create one with [Table].
+type TableValue struct {
+	rows any
+}
+
+// Table wraps rows, which should be a slice or array of structs, pointers to structs, or maps with
+// string keys, so the handler renders it as an aligned table instead of a single line:
+//
+//	logger.Info("pods", "pods", console.Table(pods))
+//
+// prints:
+//
+//	INF pods
+//	=== pods ===
+//	NAME   STATUS   RESTARTS
+//	web-1  Running  0
+//	web-2  Pending  3
+//
+// Struct columns are the exported fields, in declaration order.  Map columns are the union of
+// all keys, sorted.  Cells are formatted with fmt.Sprint.  Values which are not slices of
+// structs or maps are printed as-is.
+func Table(rows any) TableValue {
+	return TableValue{rows: rows}
+}
+
+// String implements fmt.Stringer, so tables render sensibly in other handlers too.
+func (t TableValue) String() string {
+	cols, cells, ok := t.cells()
+	if !ok {
+		return fmt.Sprint(t.rows)
+	}
+	var buf buffer
+	writeTable(&buf, cols, cells, func(s string, header bool) {
+		buf.AppendString(s)
+	})
+	return buf.String()
+}
+
+// cells extracts the column names and the formatted cells of each row.
+func (t TableValue) cells() (cols []string, cells [][]string, ok bool) {
+	v := reflect.ValueOf(t.rows)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, nil, false
+	}
+
+	elemType := v.Type().Elem()
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	switch {
+	case elemType.Kind() == reflect.Struct:
+		var fields []int
+		for i := 0; i < elemType.NumField(); i++ {
+			if f := elemType.Field(i); f.IsExported() {
+				fields = append(fields, i)
+				cols = append(cols, strings.ToUpper(f.Name))
+			}
+		}
+		for i := 0; i < v.Len(); i++ {
+			row := make([]string, len(fields))
+			if elem := indirectRow(v.Index(i)); elem.IsValid() {
+				for j, idx := range fields {
+					row[j] = fmt.Sprint(elem.Field(idx).Interface())
+				}
+			}
+			cells = append(cells, row)
+		}
+	case elemType.Kind() == reflect.Map && elemType.Key().Kind() == reflect.String:
+		seen := map[string]bool{}
+		var keys []string
+		for i := 0; i < v.Len(); i++ {
+			m := indirectRow(v.Index(i))
+			if !m.IsValid() {
+				continue
+			}
+			iter := m.MapRange()
+			for iter.Next() {
+				k := iter.Key().String()
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			cols = append(cols, strings.ToUpper(k))
+		}
+		for i := 0; i < v.Len(); i++ {
+			row := make([]string, len(keys))
+			if m := indirectRow(v.Index(i)); m.IsValid() {
+				for j, k := range keys {
+					if cell := m.MapIndex(reflect.ValueOf(k).Convert(elemType.Key())); cell.IsValid() {
+						row[j] = fmt.Sprint(cell.Interface())
+					}
+				}
+			}
+			cells = append(cells, row)
+		}
+	default:
+		return nil, nil, false
+	}
+	return cols, cells, true
+}
+
+// indirectRow follows row through any number of pointers.  It returns the zero
+// Value if it hits a nil pointer, so the row renders as empty cells.
+func indirectRow(row reflect.Value) reflect.Value {
+	for row.Kind() == reflect.Pointer {
+		if row.IsNil() {
+			return reflect.Value{}
+		}
+		row = row.Elem()
+	}
+	return row
+}
+
+// writeTable lays out the header and rows in aligned columns, calling write for each
+// cell.  Trailing whitespace is trimmed from each row.
+func writeTable(buf *buffer, cols []string, cells [][]string, write func(s string, header bool)) {
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = DisplayWidth(c)
+	}
+	for _, row := range cells {
+		for i, c := range row {
+			widths[i] = max(widths[i], DisplayWidth(c))
+		}
+	}
+
+	writeRow := func(row []string, header bool) {
+		start := len(*buf)
+		defer func() {
+			// empty trailing cells shouldn't leave trailing whitespace
+			for len(*buf) > start && (*buf)[len(*buf)-1] == ' ' {
+				*buf = (*buf)[:len(*buf)-1]
+			}
+		}()
+		for i, c := range row {
+			if i > 0 {
+				buf.AppendString("  ")
+			}
+			write(c, header)
+			if i < len(row)-1 {
+				buf.Pad(widths[i]-DisplayWidth(c), ' ')
+			}
+		}
+	}
+
+	writeRow(cols, true)
+	for _, row := range cells {
+		buf.AppendByte('\n')
+		writeRow(row, false)
+	}
+}
+
+func (e *encoder) writeTable(buf *buffer, t TableValue) {
+	cols, cells, ok := t.cells()
+	if !ok {
+		e.writeValue(buf, slog.AnyValue(t.rows))
+		return
+	}
+	writeTable(buf, cols, cells, func(s string, header bool) {
+		if header {
+			e.writeColoredString(buf, s, e.h.opts.Theme.Header)
+		} else {
+			buf.AppendString(s)
+		}
+	})
+}
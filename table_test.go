@@ -0,0 +1,98 @@
+package console
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler_Table(t *testing.T) {
+	type pod struct {
+		Name     string
+		Status   string
+		Restarts int
+		internal bool
+	}
+
+	pods := []pod{
+		{Name: "web-1", Status: "Running"},
+		{Name: "web-20", Status: "Pending", Restarts: 3},
+	}
+
+	theme := NewDefaultTheme()
+
+	tests := []handlerTest{
+		{
+			name:  "structs",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("pods", Table(pods))},
+			want:  "INF listing\n=== pods ===\nNAME    STATUS   RESTARTS\nweb-1   Running  0\nweb-20  Pending  3\n",
+		},
+		{
+			name:  "struct pointers",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("pods", Table([]*pod{&pods[0], nil}))},
+			want:  "INF listing\n=== pods ===\nNAME   STATUS   RESTARTS\nweb-1  Running  0\n\n",
+		},
+		{
+			name: "double pointers",
+			opts: HandlerOptions{NoColor: true},
+			attrs: func() []slog.Attr {
+				p := &pods[1]
+				return []slog.Attr{slog.Any("pods", Table([]**pod{&p}))}
+			}(),
+			want: "INF listing\n=== pods ===\nNAME    STATUS   RESTARTS\nweb-20  Pending  3\n",
+		},
+		{
+			name: "map pointers",
+			opts: HandlerOptions{NoColor: true},
+			attrs: func() []slog.Attr {
+				m := map[string]int{"a": 1, "b": 2}
+				return []slog.Attr{slog.Any("counts", Table([]*map[string]int{&m, nil}))}
+			}(),
+			want: "INF listing\n=== counts ===\nA  B\n1  2\n\n",
+		},
+		{
+			name: "maps",
+			opts: HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("files", Table([]map[string]any{
+				{"path": "/etc/hosts", "size": 120},
+				{"path": "/tmp", "mode": "drwx"},
+			}))},
+			want: "INF listing\n=== files ===\nMODE  PATH        SIZE\n      /etc/hosts  120\ndrwx  /tmp\n",
+		},
+		{
+			name: "wide characters",
+			opts: HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("pods", Table([]pod{
+				{Name: "日本", Status: "🚀"},
+				{Name: "web", Status: "ok"},
+			}))},
+			want: "INF listing\n=== pods ===\nNAME  STATUS  RESTARTS\n日本  🚀      0\nweb   ok      0\n",
+		},
+		{
+			name:  "not a table",
+			opts:  HandlerOptions{NoColor: true},
+			attrs: []slog.Attr{slog.Any("pods", Table("nope"))},
+			want:  "INF listing pods=nope\n",
+		},
+		{
+			name:  "colored header",
+			opts:  HandlerOptions{HeaderFormat: "%m %a", Theme: theme},
+			attrs: []slog.Attr{slog.Any("pods", Table(pods[:1]))},
+			want: styled("listing", theme.Message) + "\n" +
+				styled("=== pods ===\n", theme.AttrKey) +
+				styled("NAME", theme.Header) + "   " + styled("STATUS", theme.Header) + "   " + styled("RESTARTS", theme.Header) + "\n" +
+				"web-1  Running  0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "listing"
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestTable_String(t *testing.T) {
+	rows := []struct{ A, B string }{{"x", "long"}, {"yy", "z"}}
+	AssertEqual(t, "A   B\nx   long\nyy  z", Table(rows).String())
+}
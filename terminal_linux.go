@@ -0,0 +1,5 @@
+package console
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TCGETS
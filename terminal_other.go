@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package console
+
+import "os"
+
+// isTerminalFile reports whether f appears to be a terminal.  It's a heuristic: there's no
+// portable way to ask, so any character device counts, including ones like /dev/null.
+func isTerminalFile(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
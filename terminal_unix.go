@@ -0,0 +1,24 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package console
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminalFile reports whether f is a terminal, by asking for its terminal attributes.
+// Character devices which aren't terminals, like /dev/null, fail the request.
+func isTerminalFile(f *os.File) bool {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		var t syscall.Termios
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&t)))
+	})
+	return err == nil && errno == 0
+}
@@ -2,6 +2,7 @@ package console
 
 import (
 	"fmt"
+	"log/slog"
 )
 
 type ANSIMod string
@@ -14,6 +15,7 @@ const (
 	Faint
 	Italic
 	Underline
+	Inverse    = 7
 	CrossedOut = 9
 )
 
@@ -72,6 +74,41 @@ type Theme struct {
 	LevelWarn      ANSIMod
 	LevelInfo      ANSIMod
 	LevelDebug     ANSIMod
+	DiffInsert     ANSIMod
+	DiffDelete     ANSIMod
+	DiffHunk       ANSIMod
+	Elapsed        ANSIMod
+
+	// Styles for attribute values of particular kinds.  If empty, AttrValue is used, so
+	// themes only need to set the kinds they want to tell apart.
+	AttrValueTime     ANSIMod
+	AttrValueDuration ANSIMod
+	AttrValueBool     ANSIMod
+	AttrValueNumber   ANSIMod // ints, uints, and floats
+
+	// Styles for highlighting SQL and XML values.  Keywords include XML tags.
+	SyntaxKeyword ANSIMod
+	SyntaxString  ANSIMod
+	SyntaxComment ANSIMod
+}
+
+// valueStyle returns the style for an attribute value of the kind.
+func (t Theme) valueStyle(kind slog.Kind) ANSIMod {
+	var style ANSIMod
+	switch kind {
+	case slog.KindTime:
+		style = t.AttrValueTime
+	case slog.KindDuration:
+		style = t.AttrValueDuration
+	case slog.KindBool:
+		style = t.AttrValueBool
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+		style = t.AttrValueNumber
+	}
+	if style == "" {
+		return t.AttrValue
+	}
+	return style
 }
 
 func NewDefaultTheme() Theme {
@@ -89,6 +126,13 @@ func NewDefaultTheme() Theme {
 		LevelWarn:      ToANSICode(Yellow),
 		LevelInfo:      ToANSICode(Cyan),
 		LevelDebug:     ToANSICode(BrightMagenta),
+		DiffInsert:     ToANSICode(Green),
+		DiffDelete:     ToANSICode(Red),
+		DiffHunk:       ToANSICode(Faint, Cyan),
+		SyntaxKeyword:  ToANSICode(Blue),
+		SyntaxString:   ToANSICode(Green),
+		SyntaxComment:  ToANSICode(Faint),
+		Elapsed:        ToANSICode(Magenta),
 	}
 }
 
@@ -107,5 +151,12 @@ func NewBrightTheme() Theme {
 		LevelWarn:      ToANSICode(BrightYellow),
 		LevelInfo:      ToANSICode(BrightGreen),
 		LevelDebug:     ToANSICode(),
+		DiffInsert:     ToANSICode(BrightGreen),
+		DiffDelete:     ToANSICode(BrightRed),
+		DiffHunk:       ToANSICode(BrightCyan),
+		SyntaxKeyword:  ToANSICode(BrightBlue),
+		SyntaxString:   ToANSICode(BrightGreen),
+		SyntaxComment:  ToANSICode(Gray),
+		Elapsed:        ToANSICode(BrightMagenta),
 	}
 }
@@ -0,0 +1,42 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// ElapsedKey is the key of the duration logged by Timer.  Duration attrs with this key are
+// styled with Theme.Elapsed.
+const ElapsedKey = "elapsed"
+
+// Timer starts timing an operation, and returns a func which logs msg at info level, with
+// the time elapsed since Timer was called, and the args passed to Timer and to the func.
+// The duration is logged with the key ElapsedKey:
+//
+//	stop := console.Timer(logger, "migrated database", "db", name)
+//	...
+//	stop("tables", n)
+//
+// prints something like:
+//
+//	INF migrated database db=main tables=12 elapsed=1.2s
+func Timer(logger *slog.Logger, msg string, args ...any) func(args ...any) {
+	start := time.Now()
+	return func(stopArgs ...any) {
+		elapsed := time.Since(start)
+		ctx := context.Background()
+		if !logger.Enabled(ctx, slog.LevelInfo) {
+			return
+		}
+		var pcs [1]uintptr
+		// skip runtime.Callers and this func
+		runtime.Callers(2, pcs[:])
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, pcs[0])
+		r.Add(args...)
+		r.Add(stopArgs...)
+		r.AddAttrs(slog.Duration(ElapsedKey, elapsed))
+		_ = logger.Handler().Handle(ctx, r)
+	}
+}
@@ -0,0 +1,37 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %s %m %a", AddSource: true}))
+
+	stop := Timer(logger, "migrated", "db", "main")
+	time.Sleep(time.Millisecond)
+	stop("tables", 12)
+
+	AssertEqual(t, true, regexp.MustCompile(`^INF timer_test.go:\d+ migrated db=main tables=12 elapsed=\d+(\.\d+)?ms\n$`).MatchString(buf.String()))
+}
+
+func TestTimer_Disabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, Level: slog.LevelWarn}))
+	Timer(logger, "migrated")()
+	AssertEqual(t, "", buf.String())
+}
+
+func TestHandler_ElapsedStyle(t *testing.T) {
+	theme := NewDefaultTheme()
+	handlerTest{
+		opts:  HandlerOptions{Theme: theme, HeaderFormat: "%a"},
+		attrs: []slog.Attr{slog.Duration(ElapsedKey, time.Second), slog.Duration("timeout", time.Second)},
+		want: styled("elapsed=", theme.AttrKey) + styled("1s", theme.Elapsed) + " " +
+			styled("timeout=", theme.AttrKey) + styled("1s", theme.AttrValue) + "\n",
+	}.run(t)
+}
@@ -0,0 +1,76 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Keys of the attrs added by TraceparentAttrs.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+type traceparentKey struct{}
+
+// WithTraceparent returns a copy of ctx holding a W3C traceparent header value, like
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", for use by TraceparentAttrs.
+// It's meant for apps which propagate trace context without a tracing SDK.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
+// TraceparentAttrs returns the trace and span IDs of the traceparent stored in ctx with
+// WithTraceparent, as attrs with the keys TraceIDKey and SpanIDKey.  It returns nil if
+// there is no traceparent, or it's invalid.  Use it with Enrich, and print the IDs as
+// headers:
+//
+//	h := console.Chain(
+//		console.NewHandler(os.Stderr, &console.HandlerOptions{
+//			HeaderFormat: "%t %l %[trace_id]8h %[span_id]8h > %m %a",
+//		}),
+//		console.Enrich(console.TraceparentAttrs),
+//	)
+func TraceparentAttrs(ctx context.Context) []slog.Attr {
+	s, _ := ctx.Value(traceparentKey{}).(string)
+	traceID, spanID, ok := parseTraceparent(s)
+	if !ok {
+		return nil
+	}
+	return []slog.Attr{slog.String(TraceIDKey, traceID), slog.String(SpanIDKey, spanID)}
+}
+
+// parseTraceparent parses a traceparent header value, as specified by
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func parseTraceparent(s string) (traceID, spanID string, ok bool) {
+	s = strings.TrimSpace(s)
+	// version-traceid-spanid-flags, future versions may append more fields
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') {
+		return "", "", false
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := s[0:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(s) != 55) {
+		return "", "", false
+	}
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const traceID, spanID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"00-" + traceID + "-" + spanID + "-01", true},
+		{" 00-" + traceID + "-" + spanID + "-00 ", true},
+		{"01-" + traceID + "-" + spanID + "-01-future", true},
+		{"00-" + traceID + "-" + spanID + "-01-future", false},
+		{"ff-" + traceID + "-" + spanID + "-01", false},
+		{"00-" + traceID + "-" + spanID + "-0", false},
+		{"00-" + traceID + "_" + spanID + "-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01", false},
+		{"00-00000000000000000000000000000000-" + spanID + "-01", false},
+		{"00-" + traceID + "-0000000000000000-01", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			gotTrace, gotSpan, ok := parseTraceparent(tt.in)
+			AssertEqual(t, tt.ok, ok)
+			if ok {
+				AssertEqual(t, traceID, gotTrace)
+				AssertEqual(t, spanID, gotSpan)
+			}
+		})
+	}
+}
+
+func TestTraceparentAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := Chain(
+		NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%l %[trace_id]8h %[span_id]8h > %m %a"}),
+		Enrich(TraceparentAttrs),
+	)
+	logger := slog.New(h)
+
+	ctx := WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	logger.InfoContext(ctx, "traced")
+	logger.InfoContext(context.Background(), "untraced")
+	logger.InfoContext(WithTraceparent(context.Background(), "garbage"), "invalid")
+
+	AssertEqual(t, "INF 4bf92f35 00f067aa > traced\nINF                   > untraced\nINF                   > invalid\n", buf.String())
+}
@@ -0,0 +1,64 @@
+package console
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestHandler_Transient(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", TransientKey: "transient"})
+	h.tty = true
+	logger := slog.New(h)
+
+	logger.Info("copying", "file", "a", "transient", true)
+	AssertEqual(t, "copying file=a", buf.String())
+
+	logger.With("x", 1).Info("copying", "file", "b", "transient", true)
+	logger.Info("done", "transient", false)
+	logger.Info("next")
+	AssertEqual(t, "copying file=a\r\x1b[Kcopying x=1 file=b\r\x1b[Kdone\nnext\n", buf.String())
+}
+
+func TestHandler_TransientNotTerminal(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := slog.New(NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", TransientKey: "transient"}))
+	logger.Info("copying", "file", "a", "transient", true)
+	logger.Info("done")
+	AssertEqual(t, "copying file=a\ndone\n", buf.String())
+}
+
+func TestHandler_ClearLine(t *testing.T) {
+	buf := bytes.Buffer{}
+	h := NewHandler(&buf, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", TransientKey: "transient", ClearLine: true})
+	logger := slog.New(h)
+	logger.Info("captured")
+	AssertEqual(t, "captured\n", buf.String())
+
+	buf.Reset()
+	h.tty = true
+	logger = slog.New(h)
+	logger.Info("copying", "transient", true)
+	logger.Info("a")
+	logger.Info("b")
+	AssertEqual(t, "\r\x1b[Kcopying\r\x1b[Ka\n\r\x1b[Kb\n", buf.String())
+}
+
+func TestIsTerminal(t *testing.T) {
+	AssertEqual(t, false, isTerminal(&bytes.Buffer{}))
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	AssertNoError(t, err)
+	defer f.Close()
+	AssertEqual(t, false, isTerminal(f))
+
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		AssertNoError(t, err)
+		defer null.Close()
+		AssertEqual(t, false, isTerminal(null))
+	}
+}
@@ -0,0 +1,167 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// writeFailureThreshold is the number of consecutive failed writes after which
+// the output is considered failed.  Writes to a closed writer fail the output
+// immediately.
+const writeFailureThreshold = 3
+
+// writeProbeInterval is how often a record is written to a failed output, to find
+// out whether it has recovered.
+const writeProbeInterval = time.Second
+
+// writeState tracks whether the output is failing.
+type writeState struct {
+	// consecutive failed writes
+	failures atomic.Int64
+	// the last write error
+	err atomic.Pointer[error]
+	// when the output failed, or was last probed, in unix nanoseconds
+	probedAt atomic.Int64
+}
+
+// recordWrite updates the state with the result of a write.
+func (s *writeState) recordWrite(err error) {
+	if err == nil {
+		if s.failures.Load() != 0 {
+			s.failures.Store(0)
+			s.err.Store(nil)
+		}
+		return
+	}
+	s.recordFailure(err)
+}
+
+// recordFailure is split from recordWrite, since taking the address of err
+// would move it to the heap even for successful writes.
+func (s *writeState) recordFailure(err error) {
+	s.err.Store(&err)
+	n := s.failures.Add(1)
+	if isClosedErr(err) && n < writeFailureThreshold {
+		s.failures.Store(writeFailureThreshold)
+		n = writeFailureThreshold
+	}
+	if n >= writeFailureThreshold {
+		s.probedAt.Store(time.Now().UnixNano())
+	}
+}
+
+// failed returns the error which failed the output, or nil.
+func (s *writeState) failed() error {
+	if s.failures.Load() < writeFailureThreshold {
+		return nil
+	}
+	if err := s.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// probeDue returns true if the output is healthy, or if it's failed and it's time
+// to try writing to it again.  Unlike probe, it doesn't take the interval's slot, so
+// checking it doesn't stop the record which follows from probing.
+func (s *writeState) probeDue() bool {
+	if s.failures.Load() < writeFailureThreshold {
+		return true
+	}
+	return time.Now().UnixNano()-s.probedAt.Load() >= int64(writeProbeInterval)
+}
+
+// probe returns true if the output is healthy, or if it's failed and it's time
+// to try writing to it again.  Only one caller per interval gets true.
+func (s *writeState) probe() bool {
+	if s.failures.Load() < writeFailureThreshold {
+		return true
+	}
+	last := s.probedAt.Load()
+	now := time.Now().UnixNano()
+	if now-last < int64(writeProbeInterval) {
+		return false
+	}
+	return s.probedAt.CompareAndSwap(last, now)
+}
+
+func isClosedErr(err error) bool {
+	return errors.Is(err, fs.ErrClosed) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// writeFailed records a failed write, and reports it to OnWriteError.
+func (h *Handler) writeFailed(err error) error {
+	h.shared.writes.recordWrite(err)
+	h.shared.metrics.writeErrors.Add(1)
+	if h.opts.OnWriteError != nil {
+		h.opts.OnWriteError(err)
+	}
+	return err
+}
+
+// recoveredNotice is written to the output when it recovers, if HandlerOptions.Fallback is
+// set.
+const recoveredNotice = "console: output recovered, switching back from fallback"
+
+// writeFailedOver records a failed write, like writeFailed, and writes what's left of the
+// record to the fallback instead, if HandlerOptions.Fallback is set.  A notice is written
+// to the fallback first if this write failed the output, i.e. the failures reached
+// writeFailureThreshold, so there's one notice per failover.  It must be called with the
+// shared mutex held.
+func (h *Handler) writeFailedOver(enc *encoder, err error) error {
+	wasFailed := h.shared.writes.failed() != nil
+	err = h.writeFailed(err)
+	if h.opts.Fallback == nil {
+		return err
+	}
+	if !wasFailed && h.shared.writes.failed() != nil {
+		if _, ferr := fmt.Fprintf(h.opts.Fallback, "console: output failed, switching to fallback: %v\n", err); ferr != nil {
+			return ferr
+		}
+	}
+	return h.writeFallback(enc)
+}
+
+// writeFallback writes the record to HandlerOptions.Fallback.  Only the part which hasn't
+// been written to the output yet is written.  It must be called with the shared mutex
+// held.
+func (h *Handler) writeFallback(enc *encoder) error {
+	_, err := enc.writeTo(h.opts.Fallback)
+	return err
+}
+
+// WriteFailure returns the error from writing to the output if the output has failed,
+// or nil if it's healthy.  The output fails when the writer is closed, or after several
+// consecutive writes fail.  While it's failed, Enabled returns false, so callers don't
+// pay for formatting records which can't be written, unless HandlerOptions.Fallback is
+// set.  Once a second, Enabled returns true until a record is handled, and the first one
+// written probes whether the output has recovered; the others get the error, or go to
+// the fallback.  A successful write clears the failure.
+func (h *Handler) WriteFailure() error {
+	return h.shared.writes.failed()
+}
+
+// ShortWriteError is the error returned when the output stops accepting a record
+// part way through, without returning an error of its own.  The output then holds
+// a partial line.  It matches io.ErrShortWrite with errors.Is.
+type ShortWriteError struct {
+	// Written is the number of bytes of the record which were written.
+	Written int
+	// Len is the length of the record.
+	Len int
+}
+
+func (e *ShortWriteError) Error() string {
+	return fmt.Sprintf("short write: wrote %d of %d bytes", e.Written, e.Len)
+}
+
+func (e *ShortWriteError) Unwrap() error {
+	return io.ErrShortWrite
+}
@@ -0,0 +1,192 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+type failingWriter struct {
+	err    error
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+func TestHandler_WriteFailure(t *testing.T) {
+	ctx := context.Background()
+	w := &failingWriter{err: errors.New("boom")}
+	h := NewHandler(w, &HandlerOptions{NoColor: true})
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+
+	for i := 0; i < writeFailureThreshold-1; i++ {
+		AssertEqual[error](t, w.err, h.Handle(ctx, rec))
+		AssertEqual(t, true, h.Enabled(ctx, slog.LevelInfo))
+	}
+	AssertEqual[error](t, nil, h.WriteFailure())
+
+	AssertEqual[error](t, w.err, h.Handle(ctx, rec))
+	AssertEqual[error](t, w.err, h.WriteFailure())
+	AssertEqual(t, false, h.Enabled(ctx, slog.LevelInfo))
+	// derived handlers share the state
+	AssertEqual(t, false, h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).Enabled(ctx, slog.LevelError))
+
+	// once the probe interval passes, records are let through, and the first one
+	// written probes the output; checking Enabled doesn't use up the probe
+	h.shared.writes.probedAt.Add(-int64(writeProbeInterval))
+	AssertEqual(t, true, h.Enabled(ctx, slog.LevelInfo))
+	AssertEqual(t, true, h.Enabled(ctx, slog.LevelInfo))
+	writes := w.writes
+	AssertEqual[error](t, w.err, h.Handle(ctx, rec))
+	AssertEqual(t, writes+1, w.writes)
+	AssertEqual(t, false, h.Enabled(ctx, slog.LevelInfo))
+	// other records in the interval aren't written
+	AssertEqual[error](t, w.err, h.Handle(ctx, rec))
+	AssertEqual(t, writes+1, w.writes)
+
+	// a successful write clears the failure
+	w.err = nil
+	h.shared.writes.probedAt.Add(-int64(writeProbeInterval))
+	AssertNoError(t, h.Handle(ctx, rec))
+	AssertEqual[error](t, nil, h.WriteFailure())
+	AssertEqual(t, true, h.Enabled(ctx, slog.LevelInfo))
+}
+
+func TestHandler_WriteFailureClosed(t *testing.T) {
+	ctx := context.Background()
+	for _, err := range []error{os.ErrClosed, io.ErrClosedPipe} {
+		w := &failingWriter{err: err}
+		h := NewHandler(w, &HandlerOptions{NoColor: true})
+		AssertEqual[error](t, err, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+		AssertEqual[error](t, err, h.WriteFailure())
+		AssertEqual(t, false, h.Enabled(ctx, slog.LevelInfo))
+	}
+}
+
+func TestHandler_OnWriteError(t *testing.T) {
+	ctx := context.Background()
+	var got []error
+	w := &failingWriter{err: errors.New("boom")}
+	h := NewHandler(w, &HandlerOptions{NoColor: true, OnWriteError: func(err error) { got = append(got, err) }})
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+
+	AssertEqual[error](t, w.err, h.Handle(ctx, rec))
+	AssertEqual(t, 1, len(got))
+	AssertEqual[error](t, w.err, got[0])
+
+	w.err = nil
+	AssertNoError(t, h.Handle(ctx, rec))
+	AssertEqual(t, 1, len(got))
+
+	// a writer which stops accepting bytes without an error
+	h = NewHandler(writerFunc(func([]byte) (int, error) { return 0, nil }),
+		&HandlerOptions{NoColor: true, OnWriteError: func(err error) { got = append(got, err) }})
+	err := h.Handle(ctx, rec)
+	AssertEqual(t, 2, len(got))
+	AssertEqual[error](t, err, got[1])
+	var swe *ShortWriteError
+	AssertEqual(t, true, errors.As(err, &swe))
+	AssertEqual(t, true, errors.Is(err, io.ErrShortWrite))
+}
+
+func TestHandler_Fallback(t *testing.T) {
+	ctx := context.Background()
+	w := &failingWriter{err: errors.New("boom")}
+	fallback := bytes.Buffer{}
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m", Fallback: &fallback})
+	msg := func(m string) slog.Record {
+		return slog.NewRecord(time.Time{}, slog.LevelInfo, m, 0)
+	}
+
+	// records go to the fallback from the first failed write, with a notice once the
+	// output has failed
+	for i := 0; i < writeFailureThreshold; i++ {
+		AssertNoError(t, h.Handle(ctx, msg("a")))
+	}
+	AssertEqual(t, "a\na\nconsole: output failed, switching to fallback: boom\na\n", fallback.String())
+	AssertEqual[error](t, w.err, h.WriteFailure())
+
+	// once the output has failed, it isn't tried
+	fallback.Reset()
+	AssertEqual(t, true, h.Enabled(ctx, slog.LevelInfo))
+	AssertNoError(t, h.Handle(ctx, msg("b")))
+	AssertEqual(t, "b\n", fallback.String())
+	AssertEqual(t, writeFailureThreshold, w.writes)
+
+	// a failed probe goes to the fallback too
+	h.shared.writes.probedAt.Add(-int64(writeProbeInterval))
+	AssertNoError(t, h.Handle(ctx, msg("c")))
+	AssertEqual(t, writeFailureThreshold+1, w.writes)
+	AssertEqual(t, "b\nc\n", fallback.String())
+
+	// a successful probe switches back, with a notice
+	w.err = nil
+	out := bytes.Buffer{}
+	h.out = &out
+	h.shared.writes.probedAt.Add(-int64(writeProbeInterval))
+	AssertNoError(t, h.Handle(ctx, msg("d")))
+	AssertNoError(t, h.Handle(ctx, msg("e")))
+	AssertEqual(t, "d\n"+recoveredNotice+"\ne\n", out.String())
+	AssertEqual[error](t, nil, h.WriteFailure())
+}
+
+func TestHandler_FallbackPartialWrite(t *testing.T) {
+	var out, fallback bytes.Buffer
+	// accepts 6 bytes, then fails
+	w := writerFunc(func(b []byte) (int, error) {
+		n := min(len(b), 6-out.Len())
+		out.Write(b[:n])
+		if n < len(b) {
+			return n, errors.New("boom")
+		}
+		return n, nil
+	})
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", Fallback: &fallback, TrailerChunkSize: 4})
+
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)
+	rec.AddAttrs(slog.String("dump", "line 1\nline 2"))
+	AssertNoError(t, h.Handle(context.Background(), rec))
+	AssertEqual(t, "hello\n", out.String())
+	AssertEqual(t, "=== dump ===\nline 1\nline 2\n", fallback.String())
+}
+
+func TestHandler_FallbackTransientFailure(t *testing.T) {
+	ctx := context.Background()
+	w := &failingWriter{err: errors.New("boom")}
+	var fallback bytes.Buffer
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m", Fallback: &fallback})
+
+	// a failure below the threshold doesn't change the state, so there are no notices
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+	w.err = nil
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "b", 0)))
+	AssertEqual(t, "a\n", fallback.String())
+	AssertEqual(t, 2, w.writes)
+}
+
+func TestHandler_FallbackRecoveredSeparator(t *testing.T) {
+	ctx := context.Background()
+	w := &failingWriter{err: os.ErrClosed}
+	var out, fallback bytes.Buffer
+	h := NewHandler(w, &HandlerOptions{NoColor: true, HeaderFormat: "%m %a", Fallback: &fallback, MultilineSeparator: SeparatorBlank})
+	AssertNoError(t, h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)))
+
+	// the recovered notice isn't framed with the record
+	h.out = &out
+	h.shared.writes.probedAt.Add(-int64(writeProbeInterval))
+	rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "b", 0)
+	rec.AddAttrs(slog.String("dump", "1\n2"))
+	AssertNoError(t, h.Handle(ctx, rec))
+	AssertEqual(t, "b\n=== dump ===\n1\n2\n\n"+recoveredNotice+"\n", out.String())
+}
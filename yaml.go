@@ -0,0 +1,283 @@
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ansel1/console-slog/internal"
+)
+
+// encodeYAMLAttr renders a group attr, or a map or struct value, as an indented
+// YAML-like block in the multiline section.  Used when HandlerOptions.NestedYAML is set.
+// Returns false if the attr should be encoded normally.
+func (e *encoder) encodeYAMLAttr(groupPrefix string, a slog.Attr) bool {
+	if a.Value.Kind() != slog.KindGroup && !isYAMLCollection(a.Value) {
+		return false
+	}
+	if a.Key == "" {
+		// inline groups are expanded by encodeAttr
+		return false
+	}
+
+	offset := len(e.attrBuf)
+	if a.Value.Kind() == slog.KindGroup {
+		subgroup := a.Key
+		if groupPrefix != "" {
+			subgroup = groupPrefix + "." + a.Key
+		}
+		if e.h.opts.ReplaceAttr != nil {
+			e.groups = append(e.groups, a.Key)
+		}
+		e.writeYAMLAttrs(subgroup, a.Value.Group(), 0)
+		if e.h.opts.ReplaceAttr != nil {
+			e.groups = e.groups[:len(e.groups)-1]
+		}
+	} else {
+		if e.captureHeader(groupPrefix, a) {
+			return true
+		}
+		e.writeYAMLReflect(reflect.ValueOf(a.Value.Any()), 0)
+		e.validateYAMLAttr(groupPrefix, a)
+	}
+
+	if len(e.attrBuf) == offset {
+		// every attr in the group was elided
+		return true
+	}
+
+	// every line was written with a leading newline, drop the first one
+	val := e.attrBuf[offset+1:]
+	if internal.FeatureFlagNewMultilineAttrs {
+		e.writeMultilineAttr(a.Key, groupPrefix, val)
+	} else {
+		e.multilineAttrBuf.AppendByte(' ')
+		e.withColor(&e.multilineAttrBuf, e.h.opts.Theme.AttrKey, func() {
+			if groupPrefix != "" {
+				e.multilineAttrBuf.AppendString(groupPrefix)
+				e.multilineAttrBuf.AppendByte('.')
+			}
+			e.multilineAttrBuf.AppendString(a.Key)
+			e.multilineAttrBuf.AppendByte('=')
+		})
+		e.multilineAttrBuf.Append(e.attrBuf[offset:])
+	}
+	e.attrBuf = e.attrBuf[:offset]
+	return true
+}
+
+// writeYAMLAttrs writes each attr as a "key: value" line, nesting groups and expanding
+// inline groups.  The attrs go through the same hooks as in encodeAttr: ReplaceAttr,
+// KeyPattern, DefaultAttrs and ValidateAttr are applied, and header and error attrs are
+// captured rather than written.
+func (e *encoder) writeYAMLAttrs(groupPrefix string, attrs []slog.Attr, indent int) {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if e.h.opts.ReplaceAttr != nil && (a.Value.Kind() != slog.KindGroup || e.h.opts.ReplaceGroupAttrs) {
+			a = e.h.opts.ReplaceAttr(e.groups, a)
+			a.Value = a.Value.Resolve()
+		}
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		if e.h.opts.KeyPattern != nil && a.Key != "" {
+			a.Key = e.checkKey(a.Key)
+		}
+		if len(e.defaultsSeen) > 0 {
+			e.markDefault(groupPrefix, a.Key)
+		}
+
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			e.writeYAMLAttrs(groupPrefix, a.Value.Group(), indent)
+			continue
+		}
+
+		if a.Value.Kind() == slog.KindGroup {
+			subgroup := a.Key
+			if groupPrefix != "" {
+				subgroup = groupPrefix + "." + a.Key
+			}
+			offset := len(e.attrBuf)
+			e.writeYAMLKey(a.Key, indent)
+			l := len(e.attrBuf)
+			if e.h.opts.ReplaceAttr != nil {
+				e.groups = append(e.groups, a.Key)
+			}
+			e.writeYAMLAttrs(subgroup, a.Value.Group(), indent+2)
+			if e.h.opts.ReplaceAttr != nil {
+				e.groups = e.groups[:len(e.groups)-1]
+			}
+			if len(e.attrBuf) == l {
+				// elide empty groups
+				e.attrBuf = e.attrBuf[:offset]
+			}
+			continue
+		}
+
+		if e.captureHeader(groupPrefix, a) || e.h.hasErrorField && e.captureError(groupPrefix, a) {
+			e.validateYAMLAttr(groupPrefix, a)
+			continue
+		}
+
+		e.writeYAMLKey(a.Key, indent)
+		if isYAMLCollection(a.Value) {
+			e.writeYAMLReflect(reflect.ValueOf(a.Value.Any()), indent+2)
+		} else {
+			e.writeYAMLScalar(a.Value, indent+2)
+		}
+		e.validateYAMLAttr(groupPrefix, a)
+	}
+}
+
+// validateYAMLAttr calls ValidateAttr with the attr, and annotates the block after the
+// attr with the violation, if any.
+func (e *encoder) validateYAMLAttr(groupPrefix string, a slog.Attr) {
+	if e.h.opts.ValidateAttr == nil {
+		return
+	}
+	if err := e.h.opts.ValidateAttr(internal.JoinKey(groupPrefix, a.Key), a.Value); err != nil {
+		e.writeViolation(groupPrefix, a.Key, err)
+	}
+}
+
+func (e *encoder) writeYAMLKey(key string, indent int) {
+	e.attrBuf.AppendByte('\n')
+	e.attrBuf.Pad(indent, ' ')
+	e.writeColoredString(&e.attrBuf, key+":", e.h.opts.Theme.AttrKey)
+}
+
+// writeYAMLScalar writes a single value after a key.  Multiline values
+// are written as an indented literal block.
+func (e *encoder) writeYAMLScalar(v slog.Value, indent int) {
+	style := e.h.opts.Theme.AttrValue
+	if v.Kind() == slog.KindAny {
+		if _, ok := v.Any().(error); ok {
+			style = e.h.opts.Theme.AttrValueError
+		}
+	}
+
+	offset := len(e.attrBuf)
+	e.writeValue(&e.attrBuf, v)
+	s := string(e.attrBuf[offset:])
+	e.attrBuf = e.attrBuf[:offset]
+
+	if !strings.Contains(s, "\n") {
+		e.attrBuf.AppendByte(' ')
+		e.writeColoredString(&e.attrBuf, s, style)
+		return
+	}
+
+	e.attrBuf.AppendString(" |")
+	for _, line := range strings.Split(s, "\n") {
+		e.attrBuf.AppendByte('\n')
+		e.attrBuf.Pad(indent, ' ')
+		e.writeColoredString(&e.attrBuf, line, style)
+	}
+}
+
+// writeYAMLReflect writes maps, structs, and slices as nested blocks.  Other
+// values are written as scalars.  A value which contains itself is written
+// as "<cycle>" where it recurs.
+func (e *encoder) writeYAMLReflect(v reflect.Value, indent int) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		e.writeYAMLScalar(slog.StringValue("null"), indent)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			e.writeYAMLScalar(slog.StringValue("null"), indent)
+			return
+		}
+		ref := yamlRef{typ: v.Type(), ptr: v.Pointer()}
+		if slices.Contains(e.yamlPath, ref) {
+			e.writeYAMLScalar(slog.StringValue("<cycle>"), indent)
+			return
+		}
+		e.yamlPath = append(e.yamlPath, ref)
+		defer func() { e.yamlPath = e.yamlPath[:len(e.yamlPath)-1] }()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		if v.Len() == 0 {
+			e.writeYAMLScalar(slog.StringValue("[]"), indent)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			e.attrBuf.AppendByte('\n')
+			e.attrBuf.Pad(indent, ' ')
+			e.writeColoredString(&e.attrBuf, "-", e.h.opts.Theme.AttrKey)
+			e.writeYAMLReflect(v.Index(i), indent+2)
+		}
+		return
+	}
+
+	val := slog.AnyValue(v.Interface())
+	if !isYAMLCollection(val) {
+		e.writeYAMLScalar(val, indent)
+		return
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Map {
+		if v.Len() == 0 {
+			e.writeYAMLScalar(slog.StringValue("{}"), indent)
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			e.writeYAMLKey(fmt.Sprint(k.Interface()), indent)
+			e.writeYAMLReflect(v.MapIndex(k), indent+2)
+		}
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		e.writeYAMLKey(t.Field(i).Name, indent)
+		e.writeYAMLReflect(v.Field(i), indent+2)
+	}
+}
+
+// yamlRef identifies a pointer, map, or slice being expanded by writeYAMLReflect.
+// The type is included because a struct and its first field share an address.
+type yamlRef struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// isYAMLCollection reports whether v is a map or struct value (or a pointer to one)
+// which should be expanded into a block, rather than printed inline.  Values with
+// their own string representations are never expanded.
+func isYAMLCollection(v slog.Value) bool {
+	if v.Kind() != slog.KindAny {
+		return false
+	}
+	switch v.Any().(type) {
+	case nil, error, fmt.Stringer, *slog.Source, slog.LogValuer:
+		return false
+	}
+	rv := reflect.ValueOf(v.Any())
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Map || rv.Kind() == reflect.Struct
+}
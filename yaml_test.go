@@ -0,0 +1,178 @@
+package console
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/ansel1/console-slog/internal"
+)
+
+func TestHandler_NestedYAML(t *testing.T) {
+	type backend struct {
+		Host  string
+		Ports []int
+		Tags  map[string]string
+		notes string
+	}
+	type node struct {
+		Name string
+		Next *node
+		Any  any
+	}
+	loop := &node{Name: "a"}
+	loop.Next = &node{Name: "b", Next: loop}
+	shared := &node{Name: "s"}
+
+	tests := []handlerTest{
+		{
+			name: "group",
+			attrs: []slog.Attr{
+				slog.String("foo", "bar"),
+				slog.Group("req", slog.String("method", "GET"), slog.Group("headers", slog.String("accept", "json"))),
+			},
+			want: "INF yaml foo=bar\n=== req ===\nmethod: GET\nheaders:\n  accept: json\n",
+		},
+		{
+			name: "struct",
+			attrs: []slog.Attr{
+				slog.Any("backend", backend{Host: "db", Ports: []int{5432, 5433}, Tags: map[string]string{"z": "1", "a": "2"}}),
+			},
+			want: "INF yaml\n=== backend ===\nHost: db\nPorts:\n  - 5432\n  - 5433\nTags:\n  a: 2\n  z: 1\n",
+		},
+		{
+			name: "map pointer and nil",
+			attrs: []slog.Attr{
+				slog.Any("m", &map[string]any{"err": errors.New("boom"), "nil": nil, "empty": []string{}}),
+			},
+			want: "INF yaml\n=== m ===\nempty: []\nerr: boom\nnil: null\n",
+		},
+		{
+			name: "cycle",
+			attrs: []slog.Attr{
+				slog.Any("n", loop),
+			},
+			want: "INF yaml\n=== n ===\nName: a\nNext:\n  Name: b\n  Next: <cycle>\n  Any: null\nAny: null\n",
+		},
+		{
+			name: "cycle through interface",
+			attrs: func() []slog.Attr {
+				n := &node{Name: "a"}
+				n.Any = n
+				return []slog.Attr{slog.Any("n", n)}
+			}(),
+			want: "INF yaml\n=== n ===\nName: a\nNext: null\nAny: <cycle>\n",
+		},
+		{
+			name: "shared values are not cycles",
+			attrs: []slog.Attr{
+				slog.Any("n", node{Next: shared, Any: shared}),
+			},
+			want: "INF yaml\n=== n ===\nName: \nNext:\n  Name: s\n  Next: null\n  Any: null\nAny:\n  Name: s\n  Next: null\n  Any: null\n",
+		},
+		{
+			name: "multiline value",
+			attrs: []slog.Attr{
+				slog.Group("g", slog.String("text", "one\ntwo")),
+			},
+			want: "INF yaml\n=== g ===\ntext: |\n  one\n  two\n",
+		},
+		{
+			name: "empty groups are elided",
+			attrs: []slog.Attr{
+				slog.Group("g", slog.Group("sub")),
+			},
+			want: "INF yaml\n",
+		},
+		{
+			name: "headers extracted from groups",
+			opts: HandlerOptions{HeaderFormat: "%l %[req.id]h %m %a"},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("id", "123"), slog.String("method", "GET")),
+			},
+			want: "INF 123 yaml\n=== req ===\nmethod: GET\n",
+		},
+		{
+			name: "replace attr",
+			opts: HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) > 0 && groups[len(groups)-1] == "headers" {
+					return slog.String(a.Key, "***")
+				}
+				return a
+			}},
+			handlerFunc: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("srv")
+			},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("method", "GET"), slog.Group("headers", slog.String("auth", "secret"))),
+			},
+			want: "INF yaml\n=== srv.req ===\nmethod: GET\nheaders:\n  auth: ***\n",
+		},
+		{
+			name: "inline groups",
+			attrs: []slog.Attr{
+				slog.Group("", slog.Group("g", slog.Int("a", 1))),
+				slog.Group("req", slog.Group("", slog.String("method", "GET")), slog.Int("n", 2)),
+			},
+			want: "INF yaml\n=== g ===\na: 1\n=== req ===\nmethod: GET\nn: 2\n",
+		},
+		{
+			name: "errors extracted from groups",
+			opts: HandlerOptions{HeaderFormat: "%l %m%{: %e%} %a", ErrorKeys: []string{"req.err"}},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("err", "boom"), slog.String("method", "GET")),
+			},
+			want: "INF yaml: boom\n=== req ===\nmethod: GET\n",
+		},
+		{
+			name: "key pattern",
+			opts: HandlerOptions{KeyPattern: SnakeCaseKeys},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("userID", "1")),
+			},
+			want: "INF yaml\n=== req ===\nuserID!: 1\n",
+		},
+		{
+			name: "validate attr",
+			opts: HandlerOptions{ValidateAttr: func(key string, v slog.Value) error {
+				if key == "req.n" {
+					return errors.New("too big")
+				}
+				return nil
+			}},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.Int("n", 99), slog.String("method", "GET")),
+			},
+			want: "INF yaml\n=== req ===\nn: 99 ![req.n: too big]\nmethod: GET\n",
+		},
+		{
+			name: "default attrs",
+			opts: HandlerOptions{HeaderFormat: "%l %m %a", DefaultAttrs: []slog.Attr{slog.String("req.id", "none"), slog.String("app", "api")}},
+			attrs: []slog.Attr{
+				slog.Group("req", slog.String("id", "7")),
+			},
+			want: "INF yaml app=api\n=== req ===\nid: 7\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.msg = "yaml"
+		tt.opts.NestedYAML = true
+		tt.opts.NoColor = true
+		t.Run(tt.name, tt.run)
+	}
+
+	t.Run("old multiline", func(t *testing.T) {
+		oldValue := internal.FeatureFlagNewMultilineAttrs
+		internal.FeatureFlagNewMultilineAttrs = false
+		t.Cleanup(func() {
+			internal.FeatureFlagNewMultilineAttrs = oldValue
+		})
+		handlerTest{
+			msg:   "yaml",
+			opts:  HandlerOptions{NestedYAML: true, NoColor: true},
+			attrs: []slog.Attr{slog.Group("req", slog.String("method", "GET"))},
+			want:  "INF yaml req=\nmethod: GET\n",
+		}.run(t)
+	})
+}